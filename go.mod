@@ -2,4 +2,9 @@ module github.com/cylex-pe/core
 
 go 1.18
 
-require golang.org/x/exp v0.0.0-20220827204233-334a2380cb91 // indirect
+require (
+	go.etcd.io/bbolt v1.3.8
+	golang.org/x/exp v0.0.0-20220827204233-334a2380cb91
+)
+
+require golang.org/x/sys v0.7.0 // indirect
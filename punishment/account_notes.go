@@ -0,0 +1,103 @@
+package punishment
+
+import (
+	"sort"
+	"time"
+)
+
+// Visibility is the level of staff that may see an AccountNote. Higher levels see the notes of every level below
+// theirs.
+type Visibility int
+
+const (
+	// VisibilityStaff notes may be seen by all staff.
+	VisibilityStaff Visibility = iota
+	// VisibilitySenior notes may only be seen by senior staff and administrators.
+	VisibilitySenior
+	// VisibilityAdmin notes may only be seen by administrators.
+	VisibilityAdmin
+)
+
+// AccountNote is a note staff left on the account of a player, independent of any punishment.
+type AccountNote struct {
+	// ID is the unique identifier of the note.
+	ID string `json:"id"`
+	// Author is the name of the user that left the note.
+	Author string `json:"author"`
+	// Time is the time at which the note was left.
+	Time time.Time `json:"time"`
+	// Text is the note itself.
+	Text string `json:"text"`
+	// Visibility is the lowest level of staff that may see the note.
+	Visibility Visibility `json:"visibility"`
+	// Pinned is true if the note is pinned, which lists it before notes that aren't.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// AddNote leaves a note on the account and returns it.
+func (x *Xbox) AddNote(author, text string, visibility Visibility) AccountNote {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	n := AccountNote{ID: newCaseID(), Author: author, Time: time.Now(), Text: text, Visibility: visibility}
+	x.notes = append(x.notes, n)
+	return n
+}
+
+// PinNote pins or unpins the note with the ID passed. ErrNoteNotFound is returned if the account has no such note.
+func (x *Xbox) PinNote(id string, pinned bool) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	for i, n := range x.notes {
+		if n.ID == id {
+			x.notes[i].Pinned = pinned
+			return nil
+		}
+	}
+	return ErrNoteNotFound
+}
+
+// Notes returns the notes on the account that staff of the visibility level passed may see. Pinned notes are listed
+// first, after which notes are ordered by the time they were left.
+func (x *Xbox) Notes(level Visibility) []AccountNote {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	var notes []AccountNote
+	for _, n := range x.notes {
+		if n.Visibility <= level {
+			notes = append(notes, n)
+		}
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Pinned && !notes[j].Pinned
+	})
+	return notes
+}
+
+// AddAccountNote leaves a note on the account of the user with the xuid passed and returns it.
+func (r *Registry) AddAccountNote(xuid, author, text string, visibility Visibility) (AccountNote, error) {
+	x, err := r.Xbox(xuid)
+	if err != nil {
+		return AccountNote{}, err
+	}
+	return x.AddNote(author, text, visibility), nil
+}
+
+// PinAccountNote pins or unpins a note on the account of the user with the xuid passed. ErrNoteNotFound is returned
+// if the account has no note with the ID passed.
+func (r *Registry) PinAccountNote(xuid, id string, pinned bool) error {
+	x, err := r.Xbox(xuid)
+	if err != nil {
+		return err
+	}
+	return x.PinNote(id, pinned)
+}
+
+// AccountNotes returns the notes on the account of the user with the xuid passed that staff of the visibility level
+// passed may see, pinned notes first.
+func (r *Registry) AccountNotes(xuid string, level Visibility) ([]AccountNote, error) {
+	x, err := r.Xbox(xuid)
+	if err != nil {
+		return nil, err
+	}
+	return x.Notes(level), nil
+}
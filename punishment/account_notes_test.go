@@ -0,0 +1,47 @@
+package punishment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountNotes(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	alt, _ := r.AddAccountNote("xuid", "staff", "suspected alt of someone", VisibilityStaff)
+	_, _ = r.AddAccountNote("xuid", "admin", "parents contacted", VisibilityAdmin)
+	pinned, _ := r.AddAccountNote("xuid", "mod", "watch chat", VisibilitySenior)
+	if err := r.PinAccountNote("xuid", pinned.ID, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.PinAccountNote("xuid", "unknown", true); !errors.Is(err, ErrNoteNotFound) {
+		t.Fatalf("expected ErrNoteNotFound, got %v", err)
+	}
+
+	notes, _ := r.AccountNotes("xuid", VisibilityStaff)
+	if len(notes) != 1 || notes[0].ID != alt.ID {
+		t.Fatalf("expected staff to only see staff notes, got %+v", notes)
+	}
+	notes, _ = r.AccountNotes("xuid", VisibilitySenior)
+	if len(notes) != 2 || notes[0].ID != pinned.ID || !notes[0].Pinned {
+		t.Fatalf("expected pinned note first, got %+v", notes)
+	}
+
+	if err := r.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r = New(p, nil)
+	if notes, _ := r.AccountNotes("xuid", VisibilityAdmin); len(notes) != 3 || notes[0].ID != pinned.ID {
+		t.Fatalf("expected notes to be persisted, got %+v", notes)
+	}
+}
+
+func TestPinNoteDoesNotChangeSavedData(t *testing.T) {
+	x := &Xbox{}
+	n := x.AddNote("staff", "suspected alt", VisibilityStaff)
+	d := x.Data().(*XboxData)
+	_ = x.PinNote(n.ID, true)
+	if d.Notes[0].Pinned {
+		t.Fatalf("expected data handed out earlier not to change")
+	}
+}
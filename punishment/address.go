@@ -0,0 +1,138 @@
+package punishment
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// DefaultIpv6PrefixLength is the prefix length IPv6 addresses are grouped by unless changed using
+// Registry.SetIpv6PrefixLength. Most networks hand out a /64 to a single subscriber, within which devices pick new
+// privacy addresses regularly.
+const DefaultIpv6PrefixLength = 64
+
+// NormalizeIp parses the ip address passed and returns the key it is stored by. Ports, brackets and zones are stripped
+// and IPv4-mapped IPv6 addresses are turned into plain IPv4 addresses, so that "1.2.3.4", "::ffff:1.2.3.4" and
+// "1.2.3.4:19132" all return "1.2.3.4". IPv6 addresses are grouped by their first v6Bits bits and returned as a
+// prefix such as "2001:db8::/64". Passing a key returned by NormalizeIp returns the same key.
+func NormalizeIp(ip string, v6Bits int) (string, error) {
+	if v6Bits < 0 || v6Bits > 128 {
+		return "", fmt.Errorf("invalid ipv6 prefix length %v", v6Bits)
+	}
+	if strings.Contains(ip, "/") {
+		prefix, err := ParsePrefix(ip)
+		if err != nil {
+			return "", err
+		}
+		if prefix.Addr().Is4() || prefix.Bits() < v6Bits {
+			return "", fmt.Errorf("ip address %q is a range", ip)
+		}
+		ip = prefix.Addr().String()
+	}
+	addr, err := parseAddr(ip)
+	if err != nil {
+		return "", err
+	}
+	if addr.Is6() && v6Bits < 128 {
+		return netip.PrefixFrom(addr, v6Bits).Masked().String(), nil
+	}
+	return addr.String(), nil
+}
+
+// parseAddr parses an ip address that may hold a port, brackets or a zone. IPv4-mapped IPv6 addresses are returned as
+// IPv4 addresses.
+func parseAddr(ip string) (netip.Addr, error) {
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid ip address %q: %w", ip, err)
+	}
+	return addr.WithZone("").Unmap(), nil
+}
+
+// SetIpv6PrefixLength changes the prefix length IPv6 addresses are grouped by. Containers stored by the previous
+// prefix length are not moved, MigrateIps may be used for that.
+func (r *Registry) SetIpv6PrefixLength(bits int) error {
+	if bits < 0 || bits > 128 {
+		return fmt.Errorf("invalid ipv6 prefix length %v", bits)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ipv6Bits = bits
+	return nil
+}
+
+// ipKey returns the key an ip address is stored by in the Registry.
+func (r *Registry) ipKey(ip string) (string, error) {
+	r.lock.RLock()
+	bits := r.ipv6Bits
+	r.lock.RUnlock()
+	return NormalizeIp(ip, bits)
+}
+
+// MigrateIps merges all ip containers stored by keys that aren't normalized, such as addresses with ports or IPv6
+// addresses from before grouping, into the container of their normalized key, and normalizes the ips recorded on
+// every xbox. The provider of the Registry must implement Iterator, and must implement Deleter for the old
+// containers to be removed. Identifiers that aren't ip addresses are left alone. The number of containers merged is
+// returned. The Registry should be saved afterwards.
+func (r *Registry) MigrateIps() (int, error) {
+	it, ok := r.provider.(Iterator)
+	if !ok {
+		return 0, fmt.Errorf("provider %T can't list stored containers", r.provider)
+	}
+	var stale []Key
+	err := it.ForEach(IpIdentifier, func(k Key, _ Container) error {
+		if normalized, err := r.ipKey(k.ID); err == nil && normalized != k.ID {
+			stale = append(stale, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	merged := 0
+	for _, k := range stale {
+		c, err := r.provider.Load(k)
+		if err != nil {
+			return merged, err
+		}
+		old, ok := c.(*Ip)
+		if !ok {
+			return merged, fmt.Errorf("container type is not of type Ip")
+		}
+		ipc, err := r.Ip(k.ID)
+		if err != nil {
+			return merged, err
+		}
+		ipc.merge(old)
+		r.lock.Lock()
+		delete(r.punishments, k)
+		r.lock.Unlock()
+		if d, ok := r.provider.(Deleter); ok {
+			if err := d.Delete(k); err != nil {
+				return merged, err
+			}
+		}
+		merged++
+	}
+
+	var xuids []string
+	if err := it.ForEach(XuidIdentifier, func(k Key, _ Container) error {
+		xuids = append(xuids, k.ID)
+		return nil
+	}); err != nil {
+		return merged, err
+	}
+	for _, xuid := range xuids {
+		x, err := r.Xbox(xuid)
+		if err != nil {
+			return merged, err
+		}
+		x.normalizeIps(r.ipKey)
+	}
+	return merged, nil
+}
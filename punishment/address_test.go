@@ -0,0 +1,77 @@
+package punishment
+
+import (
+	"testing"
+)
+
+func TestNormalizeIp(t *testing.T) {
+	for ip, want := range map[string]string{
+		"1.2.3.4":                 "1.2.3.4",
+		"1.2.3.4:19132":           "1.2.3.4",
+		"::ffff:1.2.3.4":          "1.2.3.4",
+		"[::ffff:1.2.3.4]:19132":  "1.2.3.4",
+		"2001:db8:1:2:3:4:5:6":    "2001:db8:1:2::/64",
+		"[2001:db8:1:2::9]:19132": "2001:db8:1:2::/64",
+		"fe80::1%eth0":            "fe80::/64",
+		"2001:db8:1:2::/64":       "2001:db8:1:2::/64",
+	} {
+		got, err := NormalizeIp(ip, DefaultIpv6PrefixLength)
+		if err != nil {
+			t.Fatalf("%v: %v", ip, err)
+		}
+		if got != want {
+			t.Fatalf("%v: expected %v, got %v", ip, want, got)
+		}
+	}
+	if _, err := NormalizeIp("not an ip", DefaultIpv6PrefixLength); err == nil {
+		t.Fatalf("expected invalid ip to return an error")
+	}
+	if got, _ := NormalizeIp("2001:db8::1", 128); got != "2001:db8::1" {
+		t.Fatalf("expected full address with a /128 prefix length, got %v", got)
+	}
+}
+
+func TestRegistryNormalizesIps(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	r.AddAlias("player", "1.2.3.4:19132", "device", "xuid")
+	r.AddAlias("alt", "::ffff:1.2.3.4", "other", "alt")
+	ip, err := r.Ip("1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ip.Aliases()) != 2 {
+		t.Fatalf("expected both aliases on a single ip, got %v", ip.Aliases())
+	}
+	x, _ := r.Xbox("xuid")
+	if ips := x.Ips(); len(ips) != 1 || ips[0] != "1.2.3.4" {
+		t.Fatalf("expected normalized ip on xbox, got %v", ips)
+	}
+}
+
+func TestMigrateIps(t *testing.T) {
+	p := newMemoryProvider()
+	ban := NewPunishment("hacking", "staff")
+	_ = p.Save(Key{Type: IpIdentifier, ID: "1.2.3.4:19132"}, &IpData{Aliases: []Alias{{Username: "a", Xuid: "a"}}, Punishments: PunishmentsData{Active: map[Kind][]Punishment{KindBan: {ban}}}})
+	_ = p.Save(Key{Type: IpIdentifier, ID: "1.2.3.4"}, &IpData{Aliases: []Alias{{Username: "b", Xuid: "b"}}})
+	_ = p.Save(Key{Type: XuidIdentifier, ID: "a"}, &XboxData{Ips: []string{"1.2.3.4:19132", "1.2.3.4"}})
+
+	r := New(p, nil)
+	merged, err := r.MigrateIps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != 1 {
+		t.Fatalf("expected one container to be merged, got %v", merged)
+	}
+	ip, _ := r.Ip("1.2.3.4")
+	if len(ip.Aliases()) != 2 || !sameIssue(ip.CurrentBan(), ban) {
+		t.Fatalf("expected aliases and ban to be merged, got %v, %v", ip.Aliases(), ip.CurrentBan())
+	}
+	if _, ok := p.data[IpIdentifier]["1.2.3.4:19132"]; ok {
+		t.Fatalf("expected old container to be deleted")
+	}
+	x, _ := r.Xbox("a")
+	if ips := x.Ips(); len(ips) != 1 || ips[0] != "1.2.3.4" {
+		t.Fatalf("expected xbox ips to be normalized, got %v", ips)
+	}
+}
@@ -0,0 +1,307 @@
+package punishment
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"sync"
+	"time"
+)
+
+// AppealIdentifier is the punishment type of Appeals containers, which are stored by the case ID of the punishment
+// appealed.
+const AppealIdentifier = "appeal"
+
+var (
+	// ErrAppealOpen is returned when opening an appeal against a punishment that already has an open appeal.
+	ErrAppealOpen = errors.New("appeal already open")
+	// ErrNoOpenAppeal is returned when commenting on or resolving the appeal of a punishment that has no open appeal.
+	ErrNoOpenAppeal = errors.New("no open appeal")
+)
+
+// AppealStatus is the state an Appeal is in.
+type AppealStatus string
+
+const (
+	// AppealOpen is the status of appeals that haven't been resolved yet.
+	AppealOpen AppealStatus = "open"
+	// AppealAccepted is the status of appeals that were accepted, pardoning the punishment appealed.
+	AppealAccepted AppealStatus = "accepted"
+	// AppealDenied is the status of appeals that were denied, leaving the punishment appealed as it was.
+	AppealDenied AppealStatus = "denied"
+	// AppealReduced is the status of appeals that shortened the punishment appealed.
+	AppealReduced AppealStatus = "reduced"
+)
+
+// Comment is a comment left on an Appeal.
+type Comment struct {
+	// Author is the name of the user that left the comment.
+	Author string `json:"author"`
+	// Time is the time at which the comment was left.
+	Time time.Time `json:"time"`
+	// Text is the comment itself.
+	Text string `json:"text"`
+}
+
+// Appeal is an appeal against a single punishment, identified by its case ID.
+type Appeal struct {
+	// ID is the unique identifier of the appeal.
+	ID string `json:"id"`
+	// Case is the case ID of the punishment appealed.
+	Case string `json:"case"`
+	// Key is the Key of the container the punishment appealed is recorded in.
+	Key Key `json:"key"`
+	// Appellant is the name of the user that opened the appeal.
+	Appellant string `json:"appellant"`
+	// Statement is the statement the appeal was opened with.
+	Statement string `json:"statement"`
+	// Time is the time at which the appeal was opened.
+	Time time.Time `json:"time"`
+	// Status is the current status of the appeal.
+	Status AppealStatus `json:"status"`
+	// Comments holds every comment left on the appeal, in the order they were left.
+	Comments []Comment `json:"comments,omitempty"`
+	// ResolvedBy is the name of the user that resolved the appeal, empty if it is still open.
+	ResolvedBy string `json:"resolved_by,omitempty"`
+	// ResolvedAt is the time at which the appeal was resolved, it's irrelevant while the appeal is open.
+	ResolvedAt time.Time `json:"resolved_at"`
+	// Resolution is the reason given for resolving the appeal the way it was.
+	Resolution string `json:"resolution,omitempty"`
+	// ReducedTo is the new expiration time of the punishment appealed if the appeal was reduced.
+	ReducedTo time.Time `json:"reduced_to"`
+}
+
+// clone returns a copy of the appeal that doesn't share its Comments with it.
+func (a Appeal) clone() Appeal {
+	a.Comments = slices.Clone(a.Comments)
+	return a
+}
+
+// cloneAppeals returns a copy of the appeals passed that shares no memory with them.
+func cloneAppeals(appeals []Appeal) []Appeal {
+	if appeals == nil {
+		return nil
+	}
+	c := make([]Appeal, len(appeals))
+	for i, appeal := range appeals {
+		c[i] = appeal.clone()
+	}
+	return c
+}
+
+// Appeals holds every appeal against a single punishment. At most one of them is open at a time, the others being
+// kept as the trail of earlier appeals.
+type Appeals struct {
+	// appeals holds every appeal, in the order they were opened.
+	appeals []Appeal
+
+	lock sync.RWMutex
+}
+
+// AppealsData is a data representation of Appeals used for loading and saving appeals.
+type AppealsData struct {
+	// Appeals represents appeals within Appeals.
+	Appeals []Appeal `json:"appeals"`
+}
+
+func (d *AppealsData) Container() Container {
+	return &Appeals{appeals: d.Appeals}
+}
+
+// Open opens the appeal passed. ErrAppealOpen is returned if there already is an open appeal.
+func (a *Appeals) Open(appeal Appeal) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, ok := a.open(); ok {
+		return ErrAppealOpen
+	}
+	appeal.Status = AppealOpen
+	a.appeals = append(a.appeals, appeal)
+	return nil
+}
+
+// Current returns the open appeal, and false if there is none.
+func (a *Appeals) Current() (Appeal, bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if i, ok := a.open(); ok {
+		return a.appeals[i].clone(), true
+	}
+	return Appeal{}, false
+}
+
+// All returns every appeal, open or resolved, in the order they were opened.
+func (a *Appeals) All() []Appeal {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return cloneAppeals(a.appeals)
+}
+
+// Comment leaves a comment on the open appeal. ErrNoOpenAppeal is returned if there is none.
+func (a *Appeals) Comment(author, text string) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	i, ok := a.open()
+	if !ok {
+		return ErrNoOpenAppeal
+	}
+	a.appeals[i].Comments = append(a.appeals[i].Comments, Comment{Author: author, Time: time.Now(), Text: text})
+	return nil
+}
+
+// Resolve resolves the open appeal with the status passed, recording who resolved it, when and why, and returns it.
+// ErrNoOpenAppeal is returned if there is none.
+func (a *Appeals) Resolve(status AppealStatus, by, reason string) (Appeal, error) {
+	return a.resolve(status, by, reason, time.Time{})
+}
+
+// resolve resolves the open appeal like Resolve, recording reducedTo as the new expiration time of the punishment
+// appealed.
+func (a *Appeals) resolve(status AppealStatus, by, reason string, reducedTo time.Time) (Appeal, error) {
+	if status == AppealOpen {
+		return Appeal{}, fmt.Errorf("appeals can't be resolved as %v", status)
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	i, ok := a.open()
+	if !ok {
+		return Appeal{}, ErrNoOpenAppeal
+	}
+	appeal := &a.appeals[i]
+	appeal.Status, appeal.ResolvedBy, appeal.ResolvedAt, appeal.Resolution = status, by, time.Now(), reason
+	appeal.ReducedTo = reducedTo
+	return appeal.clone(), nil
+}
+
+// open returns the index of the open appeal, and false if there is none.
+func (a *Appeals) open() (int, bool) {
+	for i, appeal := range a.appeals {
+		if appeal.Status == AppealOpen {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Data returns the data representation of Appeals.
+func (a *Appeals) Data() DataHolder {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return &AppealsData{Appeals: cloneAppeals(a.appeals)}
+}
+
+// Appeals loads the Appeals against the punishment with the case ID passed.
+func (r *Registry) Appeals(id string) (*Appeals, error) {
+	id, err := normalizeCaseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return Get[*Appeals](r, Key{Type: AppealIdentifier, ID: id})
+}
+
+// OpenAppeal opens an appeal against the punishment with the case ID passed and returns it. Only active punishments
+// can be appealed, ErrNotActive being returned otherwise, and ErrAppealOpen is returned if the punishment already has
+// an open appeal.
+func (r *Registry) OpenAppeal(id, appellant, statement string) (Appeal, error) {
+	c, err := r.Case(id)
+	if err != nil {
+		return Appeal{}, err
+	}
+	if !c.Punishment.Active() {
+		return Appeal{}, ErrNotActive
+	}
+	appeals, err := r.Appeals(c.Punishment.ID)
+	if err != nil {
+		return Appeal{}, err
+	}
+	appeal := Appeal{
+		ID:        newCaseID(),
+		Case:      c.Punishment.ID,
+		Key:       c.Key,
+		Appellant: appellant,
+		Statement: statement,
+		Time:      time.Now(),
+		Status:    AppealOpen,
+	}
+	if err := appeals.Open(appeal); err != nil {
+		return Appeal{}, err
+	}
+	return appeal, nil
+}
+
+// CommentAppeal leaves a comment on the open appeal against the punishment with the case ID passed.
+// ErrNoOpenAppeal is returned if there is none.
+func (r *Registry) CommentAppeal(id, author, text string) error {
+	appeals, err := r.Appeals(id)
+	if err != nil {
+		return err
+	}
+	return appeals.Comment(author, text)
+}
+
+// AcceptAppeal accepts the open appeal against the punishment with the case ID passed, pardoning the punishment.
+// The name of the user accepting the appeal and the reason passed are recorded on both the appeal and the lifted
+// punishment. ErrNoOpenAppeal is returned if there is no open appeal.
+func (r *Registry) AcceptAppeal(id, by, reason string) (Appeal, error) {
+	return r.resolveAppeal(id, AppealAccepted, by, reason, time.Time{}, func(p caseHolder, c Case) error {
+		if err := p.LiftCase(c.Punishment.ID, by, reason); errors.Is(err, ErrNotActive) {
+			// The punishment ended before the appeal was accepted, so there is nothing left to pardon.
+			return nil
+		} else if err != nil {
+			return err
+		}
+		r.emitLifted(c.Key, p, c.Punishment.ID)
+		return nil
+	})
+}
+
+// DenyAppeal denies the open appeal against the punishment with the case ID passed, leaving the punishment as it is.
+// ErrNoOpenAppeal is returned if there is no open appeal.
+func (r *Registry) DenyAppeal(id, by, reason string) (Appeal, error) {
+	return r.resolveAppeal(id, AppealDenied, by, reason, time.Time{}, nil)
+}
+
+// ReduceAppeal resolves the open appeal against the punishment with the case ID passed by shortening the punishment,
+// so that it expires at the time passed. The time must be before the punishment would otherwise expire.
+// ErrNoOpenAppeal is returned if there is no open appeal.
+func (r *Registry) ReduceAppeal(id, by, reason string, expiration time.Time) (Appeal, error) {
+	return r.resolveAppeal(id, AppealReduced, by, reason, expiration, func(h caseHolder, c Case) error {
+		p, ok := h.(Punishable)
+		if !ok {
+			return fmt.Errorf("container type %T can't shorten punishments", h)
+		}
+		shortened, err := p.Shorten(c.Punishment.ID, expiration)
+		if err != nil {
+			return err
+		}
+		r.emit(Event{Type: EventShortened, Key: c.Key, Kind: c.Kind, Punishment: shortened})
+		return nil
+	})
+}
+
+// resolveAppeal resolves the open appeal against the punishment with the case ID passed with the status passed,
+// after calling apply with the container of the punishment, if apply isn't nil. The appeal is left open if apply
+// returns an error.
+func (r *Registry) resolveAppeal(id string, status AppealStatus, by, reason string, reducedTo time.Time, apply func(p caseHolder, c Case) error) (Appeal, error) {
+	appeals, err := r.Appeals(id)
+	if err != nil {
+		return Appeal{}, err
+	}
+	if _, ok := appeals.Current(); !ok {
+		return Appeal{}, ErrNoOpenAppeal
+	}
+	if apply != nil {
+		c, err := r.Case(id)
+		if err != nil {
+			return Appeal{}, err
+		}
+		p, err := r.caseHolder(c.Key)
+		if err != nil {
+			return Appeal{}, err
+		}
+		if err := apply(p, c); err != nil {
+			return Appeal{}, err
+		}
+	}
+	return appeals.resolve(status, by, reason, reducedTo)
+}
@@ -0,0 +1,108 @@
+package punishment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcceptAppeal(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	ban := NewTemporary(24*time.Hour, "hacking", "staff")
+	ban, _ = r.Punish(XuidKey("xuid"), KindBan, ban)
+
+	if _, err := r.OpenAppeal(ban.ID, "player", "I wasn't hacking"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.OpenAppeal(ban.ID, "player", "please"); !errors.Is(err, ErrAppealOpen) {
+		t.Fatalf("expected ErrAppealOpen, got %v", err)
+	}
+	if err := r.CommentAppeal(ban.ID, "staff", "checking the logs"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	appeal, err := r.AcceptAppeal(ban.ID, "admin", "false positive")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if appeal.Status != AppealAccepted || appeal.ResolvedBy != "admin" || len(appeal.Comments) != 1 {
+		t.Fatalf("unexpected appeal %+v", appeal)
+	}
+	x, _ := r.Xbox("xuid")
+	if x.Banned() || x.BanHistory()[0].LiftReason != "false positive" {
+		t.Fatalf("expected accepted appeal to pardon the ban")
+	}
+	if err := r.CommentAppeal(ban.ID, "staff", "late"); !errors.Is(err, ErrNoOpenAppeal) {
+		t.Fatalf("expected ErrNoOpenAppeal, got %v", err)
+	}
+
+	// The trail of the appeal is persisted through the provider.
+	if err := r.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r = New(p, nil)
+	appeals, err := r.Appeals(ban.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if all := appeals.All(); len(all) != 1 || all[0].Status != AppealAccepted {
+		t.Fatalf("expected appeal to be persisted, got %v", all)
+	}
+}
+
+func TestDenyAndReduceAppeal(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	mute := NewPunishment("toxicity", "staff")
+	mute, _ = r.Punish(XuidKey("xuid"), KindMute, mute)
+
+	_, _ = r.OpenAppeal(mute.ID, "player", "sorry")
+	if appeal, err := r.DenyAppeal(mute.ID, "admin", "not sorry enough"); err != nil || appeal.Status != AppealDenied {
+		t.Fatalf("unexpected appeal %+v, error %v", appeal, err)
+	}
+	x, _ := r.Xbox("xuid")
+	if !x.Muted() {
+		t.Fatalf("expected denied appeal to leave the mute as it was")
+	}
+
+	_, _ = r.OpenAppeal(mute.ID, "player", "really sorry")
+	expiration := time.Now().Add(time.Hour)
+	appeal, err := r.ReduceAppeal(mute.ID, "admin", "fair enough", expiration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if appeal.Status != AppealReduced || !appeal.ReducedTo.Equal(expiration) {
+		t.Fatalf("unexpected appeal %+v", appeal)
+	}
+	if m := x.CurrentMute(); !m.Expires || !m.ExpirationTime.Equal(expiration) {
+		t.Fatalf("expected mute to be shortened, got %+v", m)
+	}
+
+	_, _ = r.OpenAppeal(mute.ID, "player", "shorter please")
+	if _, err := r.ReduceAppeal(mute.ID, "admin", "longer", expiration.Add(time.Hour)); err == nil {
+		t.Fatalf("expected reducing to a later expiration to fail")
+	}
+	appeals, _ := r.Appeals(mute.ID)
+	if current, ok := appeals.Current(); !ok || current.Statement != "shorter please" {
+		t.Fatalf("expected failed reduction to leave the appeal open")
+	}
+	if len(appeals.All()) != 3 {
+		t.Fatalf("expected every appeal to be kept, got %v", appeals.All())
+	}
+}
+
+func TestCommentDoesNotChangeSavedData(t *testing.T) {
+	a := &Appeals{}
+	_ = a.Open(Appeal{ID: "appeal", Case: "case"})
+	_ = a.Comment("staff", "looking into it")
+	d := a.Data().(*AppealsData)
+	all := a.All()
+	_ = a.Comment("staff", "checked the logs")
+	_, _ = a.Resolve(AppealDenied, "staff", "no")
+	if len(d.Appeals[0].Comments) != 1 || d.Appeals[0].Status != AppealOpen || len(all[0].Comments) != 1 {
+		t.Fatalf("expected data handed out earlier not to change")
+	}
+	d.Appeals[0].Comments[0].Text = "changed"
+	if all := a.All(); all[0].Comments[0].Text != "looking into it" {
+		t.Fatalf("expected comments of data handed out not to be shared")
+	}
+}
@@ -0,0 +1,177 @@
+package boltprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cylex-pe/core/punishment"
+	"go.etcd.io/bbolt"
+)
+
+// Provider is a punishment.Provider that stores containers in a single embedded key-value database file. Every
+// punishment type is stored in its own bucket, keyed by identifier, with the data of containers encoded as JSON.
+type Provider struct {
+	db *bbolt.DB
+}
+
+// New opens the database at the path passed, creating it if it doesn't exist yet, and returns a Provider using it.
+func New(path string) (*Provider, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+	return &Provider{db: db}, nil
+}
+
+// Load loads the container stored by the Key passed. If nothing has been stored for the Key yet, an empty container
+// is returned.
+func (p *Provider) Load(key punishment.Key) (punishment.Container, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	var data punishment.DataHolder
+	err := p.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(key.Type))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(key.ID))
+		if v == nil {
+			return nil
+		}
+		data, _ = punishment.NewDataHolder(key.Type)
+		return json.Unmarshal(v, data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load %v: %w", key, err)
+	}
+	if data == nil {
+		c, _ := punishment.NewContainer(key.Type)
+		return c, nil
+	}
+	return data.Container(), nil
+}
+
+// Save stores the data passed under the ID of the Key in the bucket of its punishment type.
+func (p *Provider) Save(key punishment.Key, data punishment.DataHolder) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	v, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("unable to encode %v: %w", key, err)
+	}
+	err = p.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(key.Type))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key.ID), v)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to save %v: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes the data stored by the Key passed. Deleting a Key that isn't stored is not an error.
+func (p *Provider) Delete(key punishment.Key) error {
+	err := p.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(key.Type))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key.ID))
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete %v: %w", key, err)
+	}
+	return nil
+}
+
+// ForEach calls f for every container stored for a punishment type, in order of their identifiers. Iteration stops
+// as soon as f returns an error, which is then returned by ForEach. The containers are read up front, so f may save
+// to or delete from the Provider while iterating.
+func (p *Provider) ForEach(ptype string, f func(key punishment.Key, c punishment.Container) error) error {
+	if _, ok := punishment.NewDataHolder(ptype); !ok {
+		return fmt.Errorf("unknown punishment type %v", ptype)
+	}
+	var ids []string
+	var values [][]byte
+	err := p.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(ptype))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			ids = append(ids, string(k))
+			values = append(values, append([]byte(nil), v...))
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("unable to read %v: %w", ptype, err)
+	}
+	for i, id := range ids {
+		key := punishment.Key{Type: ptype, ID: id}
+		data, _ := punishment.NewDataHolder(ptype)
+		if err := json.Unmarshal(values[i], data); err != nil {
+			return fmt.Errorf("unable to decode %v: %w", key, err)
+		}
+		if err := f(key, data.Container()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// casesBucket is the bucket the index of case IDs is stored in.
+var casesBucket = []byte(".cases")
+
+// Index records the Key of the container the punishment with the case ID passed is recorded in.
+func (p *Provider) Index(id string, key punishment.Key) error {
+	v, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("unable to encode %v: %w", key, err)
+	}
+	err = p.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(casesBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(id), v)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to index case %v: %w", id, err)
+	}
+	return nil
+}
+
+// Lookup returns the Key of the container the punishment with the case ID passed is recorded in, and false if the
+// case ID isn't indexed.
+func (p *Provider) Lookup(id string) (punishment.Key, bool, error) {
+	var key punishment.Key
+	var ok bool
+	err := p.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(casesBucket)
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(v, &key)
+	})
+	if err != nil {
+		return punishment.Key{}, false, fmt.Errorf("unable to look up case %v: %w", id, err)
+	}
+	return key, ok, nil
+}
+
+// Close closes the underlying database. The Provider may not be used after calling Close.
+func (p *Provider) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,158 @@
+package boltprovider
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cylex-pe/core/punishment"
+)
+
+// open opens a Provider on the database at the path passed, closing it once the test finishes.
+func open(t *testing.T, path string) *Provider {
+	t.Helper()
+	p, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "punishments.db")
+	p := open(t, path)
+	x := &punishment.Xbox{}
+	if err := x.Ban(punishment.NewTemporary(time.Hour, "hacking", "staff")); err != nil {
+		t.Fatal(err)
+	}
+	x.AddIp("1.2.3.4")
+	key := punishment.XuidKey("xuid")
+	if err := p.Save(key, x.Data()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the database to make sure the data was written to disk rather than kept in memory.
+	p = open(t, path)
+	c, err := p.Load(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, ok := c.(*punishment.Xbox)
+	if !ok {
+		t.Fatalf("expected *punishment.Xbox, got %T", c)
+	}
+	if !loaded.Banned() || loaded.CurrentBan().ID != x.CurrentBan().ID || len(loaded.Ips()) != 1 {
+		t.Fatalf("expected saved xbox to be loaded, got ban %+v and ips %v", loaded.CurrentBan(), loaded.Ips())
+	}
+}
+
+func TestLoadUnknownIdentifier(t *testing.T) {
+	p := open(t, filepath.Join(t.TempDir(), "punishments.db"))
+	c, err := p.Load(punishment.DeviceKey("unknown"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := c.(*punishment.Device)
+	if !ok || d.Banned() || len(d.Aliases()) != 0 {
+		t.Fatalf("expected an empty device, got %#v", c)
+	}
+	if _, err := p.Load(punishment.Key{Type: "unknown", ID: "id"}); err == nil {
+		t.Fatalf("expected unknown types to be refused")
+	}
+}
+
+func TestForEachAndDelete(t *testing.T) {
+	p := open(t, filepath.Join(t.TempDir(), "punishments.db"))
+	if err := p.ForEach(punishment.IpIdentifier, func(punishment.Key, punishment.Container) error {
+		t.Fatalf("expected no containers in an empty database")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	keys := []punishment.Key{
+		punishment.IpKey("2001:db8::/64"),
+		punishment.IpKey("#0123456789abcdef0123456789abcdef"),
+		punishment.IpKey("1.2.3.4"),
+	}
+	for _, k := range keys {
+		ip := &punishment.Ip{}
+		ip.AddAlias(punishment.Alias{Username: k.ID, Xuid: "xuid"})
+		if err := p.Save(k, ip.Data()); err != nil {
+			t.Fatalf("saving %v: %v", k, err)
+		}
+	}
+
+	var seen []string
+	err := p.ForEach(punishment.IpIdentifier, func(k punishment.Key, c punishment.Container) error {
+		if a := c.(*punishment.Ip).Aliases(); len(a) != 1 || a[0].Username != k.ID {
+			t.Fatalf("container of %v holds %v", k, a)
+		}
+		seen = append(seen, k.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(seen)
+	if want := []string{"#0123456789abcdef0123456789abcdef", "1.2.3.4", "2001:db8::/64"}; strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+
+	if err := p.Delete(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Delete(keys[0]); err != nil {
+		t.Fatalf("expected deleting a missing key to succeed, got %v", err)
+	}
+	if c, _ := p.Load(keys[0]); len(c.(*punishment.Ip).Aliases()) != 0 {
+		t.Fatalf("expected deleted key to load empty")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	p := open(t, filepath.Join(t.TempDir(), "punishments.db"))
+	if _, ok, err := p.Lookup("0123456789ab"); err != nil || ok {
+		t.Fatalf("expected missing case to not be found, got %v, %v", ok, err)
+	}
+	key := punishment.DeviceKey("#0123456789abcdef0123456789abcdef")
+	if err := p.Index("0123456789ab", key); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := p.Lookup("0123456789ab")
+	if err != nil || !ok || got != key {
+		t.Fatalf("expected %v, got %v, %v, %v", key, got, ok, err)
+	}
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	p := open(t, filepath.Join(t.TempDir(), "punishments.db"))
+	r := punishment.New(p, nil)
+	ban, err := r.Punish(punishment.IpKey("2001:db8::1"), punishment.KindBan, punishment.NewPunishment("botnet", "staff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddNote(ban.ID, "staff", "seen on several accounts"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r = punishment.New(p, nil)
+	c, err := r.Case(ban.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Key != punishment.IpKey("2001:db8::/64") {
+		t.Fatalf("unexpected key %v", c.Key)
+	}
+	if !c.Punishment.Active() || len(c.Punishment.Notes()) != 1 {
+		t.Fatalf("expected ban and note to be persisted, got %+v", c.Punishment)
+	}
+}
@@ -0,0 +1,229 @@
+package punishment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCaseNotFound is returned when looking up a case ID that no punishment was issued with.
+var ErrCaseNotFound = errors.New("case not found")
+
+const (
+	// caseAlphabet is the lower case Crockford base32 alphabet case IDs are encoded in. It is in ascending order, so
+	// case IDs sort the same way as the values they encode.
+	caseAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+	// caseIDLength is the length of case IDs. Each character encodes 5 bits, 42 of which hold the time in milliseconds
+	// and 18 of which hold a sequence number.
+	caseIDLength = 12
+	// caseSeqBits is the number of bits of case IDs holding the sequence number.
+	caseSeqBits = 18
+)
+
+var (
+	// caseMu guards lastCaseMs and lastCaseSeq.
+	caseMu sync.Mutex
+	// lastCaseMs and lastCaseSeq are the time and sequence number of the last case ID generated.
+	lastCaseMs, lastCaseSeq int64
+)
+
+// newCaseID returns a new case ID. Case IDs are short, sort by the time they were generated in and never repeat within
+// a process. The sequence number starts at a random value every millisecond, so that IDs generated by different
+// processes are unlikely to collide.
+func newCaseID() string {
+	caseMu.Lock()
+	defer caseMu.Unlock()
+	ms := time.Now().UnixMilli()
+	if ms <= lastCaseMs {
+		ms, lastCaseSeq = lastCaseMs, lastCaseSeq+1
+		if lastCaseSeq >= 1<<caseSeqBits {
+			ms, lastCaseSeq = ms+1, 0
+		}
+	} else {
+		lastCaseSeq = rand.Int63n(1 << (caseSeqBits - 1))
+	}
+	lastCaseMs = ms
+	return encodeCaseID(ms, lastCaseSeq)
+}
+
+// legacyCaseID returns the case ID of a punishment of the Kind passed that was stored without one. The ID is derived
+// from the punishment, so that it is the same every time the punishment is loaded until it's saved with it. n tells
+// apart identical punishments stored in the same container.
+func legacyCaseID(k Kind, p Punishment, n int) string {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%v\x00%v\x00%v\x00%v", k, p.PunishmentReason, p.PunishmentIssuer, n)
+	return encodeCaseID(p.Time.UnixMilli(), int64(h.Sum32())&(1<<caseSeqBits-1))
+}
+
+// encodeCaseID encodes the time in milliseconds and sequence number passed into a case ID.
+func encodeCaseID(ms, seq int64) string {
+	v := uint64(ms)<<caseSeqBits | uint64(seq)
+	b := make([]byte, caseIDLength)
+	for i := caseIDLength - 1; i >= 0; i-- {
+		b[i] = caseAlphabet[v&31]
+		v >>= 5
+	}
+	return string(b)
+}
+
+// normalizeCaseID normalizes a case ID typed by a user, which may be in upper case or prefixed with a '#'. An error is
+// returned if it isn't a valid case ID.
+func normalizeCaseID(id string) (string, error) {
+	id = strings.ToLower(strings.TrimPrefix(id, "#"))
+	if len(id) != caseIDLength || strings.Trim(id, caseAlphabet) != "" {
+		return "", fmt.Errorf("invalid case id %q", id)
+	}
+	return id, nil
+}
+
+// caseHolder is a container holding punishments that can be looked up by their case IDs. Every Punishable
+// implements it, as does Ranges.
+type caseHolder interface {
+	Container
+	// Record returns all punishments held.
+	Record() []Entry
+	// Case returns the punishment with a case ID.
+	Case(id string) (Entry, bool)
+	// LiftCase lifts the punishment with a case ID.
+	LiftCase(id, by, reason string) error
+}
+
+// Case is a punishment found by its case ID, along with the container it is recorded in.
+type Case struct {
+	// Key is the Key of the container the punishment is recorded in.
+	Key Key
+	// Kind is the Kind the punishment was issued as.
+	Kind Kind
+	// Punishment is the punishment itself.
+	Punishment Punishment
+}
+
+// Case looks up the punishment with the case ID passed. Loaded containers are searched first, after which the index
+// of the provider is used if it implements Indexer. ErrCaseNotFound is returned if the punishment can't be found.
+func (r *Registry) Case(id string) (Case, error) {
+	id, err := normalizeCaseID(id)
+	if err != nil {
+		return Case{}, err
+	}
+	for _, l := range r.loaded() {
+		if p, ok := l.container.(caseHolder); ok {
+			if e, ok := p.Case(id); ok {
+				return Case{Key: l.key, Kind: e.Kind, Punishment: e.Punishment}, nil
+			}
+		}
+	}
+	idx, ok := r.provider.(Indexer)
+	if !ok {
+		return Case{}, ErrCaseNotFound
+	}
+	key, ok, err := idx.Lookup(id)
+	if err != nil {
+		return Case{}, fmt.Errorf("unable to look up case: %w", err)
+	}
+	if !ok {
+		return Case{}, ErrCaseNotFound
+	}
+	p, err := r.caseHolder(key)
+	if err != nil {
+		return Case{}, err
+	}
+	e, ok := p.Case(id)
+	if !ok {
+		return Case{}, ErrCaseNotFound
+	}
+	return Case{Key: key, Kind: e.Kind, Punishment: e.Punishment}, nil
+}
+
+// LiftCase lifts the punishment with the case ID passed, recording who lifted it and why. ErrCaseNotFound is returned
+// if the punishment can't be found, and ErrNotActive if it isn't active.
+func (r *Registry) LiftCase(id, by, reason string) error {
+	c, err := r.Case(id)
+	if err != nil {
+		return err
+	}
+	p, err := r.caseHolder(c.Key)
+	if err != nil {
+		return err
+	}
+	if err := p.LiftCase(c.Punishment.ID, by, reason); err != nil {
+		return err
+	}
+	r.emitLifted(c.Key, p, c.Punishment.ID)
+	return nil
+}
+
+// Reindex adds every punishment of every stored container to the index of the provider, which must implement both
+// Iterator and Indexer. It is used to index punishments that were stored before the provider kept an index. The
+// number of punishments indexed is returned.
+func (r *Registry) Reindex() (int, error) {
+	it, ok := r.provider.(Iterator)
+	if !ok {
+		return 0, fmt.Errorf("provider %T can't list stored containers", r.provider)
+	}
+	idx, ok := r.provider.(Indexer)
+	if !ok {
+		return 0, fmt.Errorf("provider %T can't index cases", r.provider)
+	}
+	indexed := 0
+	for _, t := range Types() {
+		err := it.ForEach(t.Name, func(k Key, c Container) error {
+			p, ok := c.(caseHolder)
+			if !ok {
+				return nil
+			}
+			for _, e := range p.Record() {
+				if err := idx.Index(e.Punishment.ID, k); err != nil {
+					return err
+				}
+				indexed++
+			}
+			return nil
+		})
+		if err != nil {
+			return indexed, err
+		}
+	}
+	return indexed, nil
+}
+
+// index adds the punishments of the container passed that weren't indexed yet to the index of the provider, if it
+// implements Indexer.
+func (r *Registry) index(key Key, c Container) error {
+	idx, ok := r.provider.(Indexer)
+	if !ok {
+		return nil
+	}
+	p, ok := c.(caseHolder)
+	if !ok {
+		return nil
+	}
+	r.indexLock.Lock()
+	defer r.indexLock.Unlock()
+	for _, e := range p.Record() {
+		if _, ok := r.indexed[e.Punishment.ID]; ok {
+			continue
+		}
+		if err := idx.Index(e.Punishment.ID, key); err != nil {
+			return err
+		}
+		r.indexed[e.Punishment.ID] = struct{}{}
+	}
+	return nil
+}
+
+// caseHolder loads the container with the Key passed and returns it if it holds punishments with case IDs.
+func (r *Registry) caseHolder(key Key) (caseHolder, error) {
+	c, err := r.Load(key)
+	if err != nil {
+		return nil, err
+	}
+	h, ok := c.(caseHolder)
+	if !ok {
+		return nil, fmt.Errorf("container type %T can't hold punishments", c)
+	}
+	return h, nil
+}
@@ -0,0 +1,163 @@
+package punishment
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCaseIDsAreUniqueAndSortable(t *testing.T) {
+	ids := make([]string, 1000)
+	seen := map[string]bool{}
+	for i := range ids {
+		ids[i] = newCaseID()
+		if seen[ids[i]] {
+			t.Fatalf("duplicate case id %v", ids[i])
+		}
+		seen[ids[i]] = true
+		if _, err := normalizeCaseID(ids[i]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Fatalf("expected case ids to sort in the order they were generated")
+	}
+}
+
+func TestCaseLookup(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	ban, err := r.Punish(DeviceKey("device"), KindBan, NewTemporary(time.Hour, "hacking", "staff"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := r.Case("#" + ban.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Key != DeviceKey("device") || c.Kind != KindBan || !reflect.DeepEqual(c.Punishment, ban) {
+		t.Fatalf("unexpected case %+v", c)
+	}
+	if err := r.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A fresh Registry has nothing loaded, so it has to use the index of the provider.
+	r = New(p, nil)
+	if err := r.LiftCase(ban.ID, "admin", "appeal"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.LiftCase(ban.ID, "admin", "appeal"); !errors.Is(err, ErrNotActive) {
+		t.Fatalf("expected ErrNotActive, got %v", err)
+	}
+	if _, err := r.Case(newCaseID()); !errors.Is(err, ErrCaseNotFound) {
+		t.Fatalf("expected ErrCaseNotFound, got %v", err)
+	}
+	if _, err := r.Case("../../etc"); err == nil {
+		t.Fatalf("expected invalid case id to be refused")
+	}
+}
+
+func TestLegacyPunishmentsGetStableCaseIDs(t *testing.T) {
+	ban := NewPunishment("hacking", "staff")
+	ban.ID = ""
+	d := XboxData{legacyData: legacyData{CurrentBan: &ban}}
+	first := d.Container().(*Xbox).CurrentBan().ID
+	second := d.Container().(*Xbox).CurrentBan().ID
+	if first == "" || first != second {
+		t.Fatalf("expected stable case id, got %q and %q", first, second)
+	}
+}
+
+func TestPunishingSeveralContainersGivesEachACase(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	ban := NewPunishment("hacking", "staff")
+	onXbox, _ := r.Punish(XuidKey("xuid"), KindBan, ban)
+	onDevice, _ := r.Punish(DeviceKey("device"), KindBan, ban)
+	if onXbox.ID == onDevice.ID {
+		t.Fatalf("expected separate case ids, got %v twice", onXbox.ID)
+	}
+	if err := r.LiftCase(onXbox.ID, "admin", "appeal"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, _ := r.Device("device")
+	if !d.Banned() {
+		t.Fatalf("expected lifting the xbox ban to leave the device ban")
+	}
+
+	// Issuing the same punishment on several containers directly must give each a case of its own too.
+	x, _ := r.Xbox("other")
+	ip, _ := r.Ip("1.2.3.4")
+	ban = NewPunishment("hacking", "staff")
+	if err := x.Ban(ban); err != nil {
+		t.Fatal(err)
+	}
+	if err := ip.Ban(ban); err != nil {
+		t.Fatal(err)
+	}
+	if x.CurrentBan().ID == "" || x.CurrentBan().ID == ip.CurrentBan().ID {
+		t.Fatalf("expected separate case ids, got %v and %v", x.CurrentBan().ID, ip.CurrentBan().ID)
+	}
+	if err := x.Ban(x.CurrentBan()); err == nil {
+		t.Fatalf("expected issuing a case id twice on a container to be refused")
+	}
+	if err := r.LiftCase(x.CurrentBan().ID, "admin", "appeal"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if x.Banned() || !ip.Banned() {
+		t.Fatalf("expected lifting the xbox ban to leave the ip ban")
+	}
+}
+
+func TestLegacyHistoryCaseIDs(t *testing.T) {
+	old := NewPunishment("spam", "staff")
+	old.ID = ""
+	d := XboxData{legacyData: legacyData{PastBans: []Punishment{{}, old, old}}}
+	h := d.Container().(*Xbox).BanHistory()
+	if len(h) != 2 {
+		t.Fatalf("expected empty history entries to be dropped, got %v", h)
+	}
+	if h[0].ID == h[1].ID {
+		t.Fatalf("expected identical legacy punishments to get separate case ids")
+	}
+}
+
+func TestRangeBanCases(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	ban, err := r.BanRange("10.0.0.0/8", NewPunishment("botnet", "staff"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = r.Save()
+	r = New(p, nil)
+	c, err := r.Case(ban.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Key != rangesKey || c.Kind != KindBan {
+		t.Fatalf("unexpected case %+v", c)
+	}
+	if err := r.LiftCase(ban.ID, "admin", "isp fixed it"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := r.CheckLogin("user", "xuid", "10.1.2.3", ""); !v.Allowed {
+		t.Fatalf("expected lifted range ban not to deny login")
+	}
+}
+
+func TestDataIsNotChangedByLaterUpdates(t *testing.T) {
+	x := &Xbox{}
+	_ = x.Punish(KindBan, NewPunishment("hacking", "staff"))
+	id := x.CurrentBan().ID
+	d := x.Data().(*XboxData)
+	_ = x.Update(id, func(p *Punishment) error {
+		p.PunishmentReason = "changed"
+		return nil
+	})
+	if d.Punishments.Active[KindBan][0].Reason() != "hacking" {
+		t.Fatalf("expected data handed out earlier not to change")
+	}
+}
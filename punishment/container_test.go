@@ -0,0 +1,229 @@
+package punishment
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// punishable is implemented by every container that embeds Punishments.
+type punishable interface {
+	Punishable
+	Ban(b Punishment) error
+	Banned() bool
+	CurrentBan() Punishment
+	BanHistory() []Punishment
+	Unban(by, reason string) error
+	Mute(m Punishment) error
+	Muted() bool
+	CurrentMute() Punishment
+	MuteHistory() []Punishment
+	Unmute(by, reason string) error
+}
+
+// containers returns a fresh instance of every container type that holds bans and mutes.
+// sameIssue returns whether the punishment issued, a, is the punishment b apart from the case ID the container
+// assigned it if b had none.
+func sameIssue(a, b Punishment) bool {
+	if a.ID == "" || b.ID != "" && a.ID != b.ID {
+		return false
+	}
+	a.ID, b.ID = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+func containers() map[string]func() punishable {
+	return map[string]func() punishable{
+		"xbox":   func() punishable { return &Xbox{} },
+		"ip":     func() punishable { return &Ip{} },
+		"device": func() punishable { return &Device{} },
+	}
+}
+
+func TestMuteDoesNotBan(t *testing.T) {
+	for name, newContainer := range containers() {
+		t.Run(name, func(t *testing.T) {
+			c := newContainer()
+			m := NewPunishment("spam", "staff")
+			c.Mute(m)
+
+			if !c.Muted() {
+				t.Fatalf("expected container to be muted")
+			}
+			if !sameIssue(c.CurrentMute(), m) {
+				t.Fatalf("expected current mute %v, got %v", m, c.CurrentMute())
+			}
+			if c.Banned() {
+				t.Fatalf("muting should not ban")
+			}
+			if !c.CurrentBan().Empty() {
+				t.Fatalf("expected no current ban, got %v", c.CurrentBan())
+			}
+			if len(c.BanHistory()) != 0 {
+				t.Fatalf("muting should not add to the ban history, got %v", c.BanHistory())
+			}
+		})
+	}
+}
+
+func TestBanDoesNotMute(t *testing.T) {
+	for name, newContainer := range containers() {
+		t.Run(name, func(t *testing.T) {
+			c := newContainer()
+			b := NewPunishment("hacking", "staff")
+			c.Ban(b)
+
+			if !c.Banned() {
+				t.Fatalf("expected container to be banned")
+			}
+			if !sameIssue(c.CurrentBan(), b) {
+				t.Fatalf("expected current ban %v, got %v", b, c.CurrentBan())
+			}
+			if c.Muted() {
+				t.Fatalf("banning should not mute")
+			}
+			if len(c.MuteHistory()) != 0 {
+				t.Fatalf("banning should not add to the mute history, got %v", c.MuteHistory())
+			}
+		})
+	}
+}
+
+func TestHistoriesAreSeparate(t *testing.T) {
+	for name, newContainer := range containers() {
+		t.Run(name, func(t *testing.T) {
+			c := newContainer()
+			b1, b2 := NewPunishment("hacking", "staff"), NewPunishment("hacking again", "staff")
+			m1, m2 := NewPunishment("spam", "staff"), NewPunishment("more spam", "staff")
+			c.Ban(b1)
+			c.Mute(m1)
+			c.Ban(b2)
+			c.Mute(m2)
+
+			if h := c.BanHistory(); len(h) != 1 || !sameIssue(h[0], b1) {
+				t.Fatalf("expected ban history [%v], got %v", b1, h)
+			}
+			if h := c.MuteHistory(); len(h) != 1 || !sameIssue(h[0], m1) {
+				t.Fatalf("expected mute history [%v], got %v", m1, h)
+			}
+			if !sameIssue(c.CurrentBan(), b2) {
+				t.Fatalf("expected current ban %v, got %v", b2, c.CurrentBan())
+			}
+			if !sameIssue(c.CurrentMute(), m2) {
+				t.Fatalf("expected current mute %v, got %v", m2, c.CurrentMute())
+			}
+		})
+	}
+}
+
+func TestDataRoundTrip(t *testing.T) {
+	for name, newContainer := range containers() {
+		t.Run(name, func(t *testing.T) {
+			c := newContainer()
+			b1, b2 := NewPunishment("hacking", "staff"), NewPunishment("hacking again", "staff")
+			m1, m2 := NewPunishment("spam", "staff"), NewPunishment("more spam", "staff")
+			c.Ban(b1)
+			c.Ban(b2)
+			c.Mute(m1)
+			c.Mute(m2)
+
+			loaded, ok := c.Data().Container().(punishable)
+			if !ok {
+				t.Fatalf("container decoded to unexpected type %T", c.Data().Container())
+			}
+			if !sameIssue(loaded.CurrentBan(), b2) || !sameIssue(loaded.CurrentMute(), m2) {
+				t.Fatalf("current punishments were not kept: ban %v, mute %v", loaded.CurrentBan(), loaded.CurrentMute())
+			}
+			if h := loaded.BanHistory(); len(h) != 1 || !sameIssue(h[0], b1) {
+				t.Fatalf("expected ban history [%v], got %v", b1, h)
+			}
+			if h := loaded.MuteHistory(); len(h) != 1 || !sameIssue(h[0], m1) {
+				t.Fatalf("expected mute history [%v], got %v", m1, h)
+			}
+		})
+	}
+}
+
+func TestExpiredPunishmentsAreInactive(t *testing.T) {
+	for name, newContainer := range containers() {
+		t.Run(name, func(t *testing.T) {
+			c := newContainer()
+			c.Ban(NewTemporary(-time.Minute, "hacking", "staff"))
+			c.Mute(NewTemporary(time.Hour, "spam", "staff"))
+
+			if c.Banned() {
+				t.Fatalf("expected expired ban to be inactive")
+			}
+			if !c.Muted() {
+				t.Fatalf("expected unexpired mute to be active")
+			}
+		})
+	}
+}
+
+func TestPardon(t *testing.T) {
+	for name, newContainer := range containers() {
+		t.Run(name, func(t *testing.T) {
+			c := newContainer()
+			if err := c.Unban("staff", "mistake"); !errors.Is(err, ErrNotBanned) {
+				t.Fatalf("expected ErrNotBanned, got %v", err)
+			}
+			c.Ban(NewPunishment("hacking", "staff"))
+			c.Mute(NewPunishment("spam", "staff"))
+			if err := c.Unban("admin", "false positive"); err != nil {
+				t.Fatal(err)
+			}
+			if c.Banned() || !c.CurrentBan().Empty() {
+				t.Fatalf("expected ban to be lifted, got %v", c.CurrentBan())
+			}
+			h := c.BanHistory()
+			if len(h) != 1 || !h[0].Lifted() || h[0].LiftedBy != "admin" || h[0].LiftReason != "false positive" {
+				t.Fatalf("expected lifted ban with audit metadata in history, got %v", h)
+			}
+			if !c.Muted() {
+				t.Fatalf("unbanning should not lift the mute")
+			}
+			if err := c.Unmute("admin", "appeal"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Unmute("admin", "appeal"); !errors.Is(err, ErrNotMuted) {
+				t.Fatalf("expected ErrNotMuted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	x := &Xbox{}
+	warning := NewPunishment("spam", "staff")
+	mute := NewPunishment("spam again", "staff")
+	kick := NewPunishment("toxicity", "staff")
+	ban := NewPunishment("hacking", "staff")
+	x.Warn(warning)
+	x.Mute(mute)
+	x.Kick(kick)
+	x.Ban(ban)
+
+	if w := x.Warnings(); len(w) != 1 || !sameIssue(w[0], warning) {
+		t.Fatalf("expected warning to be recorded, got %v", w)
+	}
+	if k := x.Kicks(); len(k) != 1 || !sameIssue(k[0], kick) {
+		t.Fatalf("expected kick to be recorded, got %v", k)
+	}
+	want := []Entry{{KindWarning, warning}, {KindMute, mute}, {KindKick, kick}, {KindBan, ban}}
+	record := x.Record()
+	if len(record) != len(want) {
+		t.Fatalf("expected %v entries, got %v", len(want), record)
+	}
+	for i, e := range record {
+		if e.Kind != want[i].Kind || !sameIssue(e.Punishment, want[i].Punishment) {
+			t.Fatalf("entry %v: expected %v, got %v", i, want[i], e)
+		}
+	}
+
+	loaded := x.Data().Container().(*Xbox)
+	if len(loaded.Warnings()) != 1 || len(loaded.Kicks()) != 1 {
+		t.Fatalf("expected warnings and kicks to be kept in XboxData")
+	}
+}
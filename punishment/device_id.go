@@ -7,45 +7,31 @@ import (
 
 // Device holds all device related punishments for a user as well as their aliases.
 type Device struct {
+	// Punishments holds every punishment issued on the device-id.
+	Punishments
 	// aliases represent the information of other accounts that have the same device-id as this one.
 	aliases []Alias
-	// currentBan holds a users current device ban, if they're  not currently device banned it will be the default value.
-	currentBan Punishment
-	//pastBans holds all a users past device bans.
-	pastBans []Punishment
-	// currentMute is the players current active mute, this value will be the default value for Punishment if their not muted.
-	currentMute Punishment
-	// pastMutes store a history of all the users past mutes.
-	pastMutes []Punishment
 
 	lock sync.RWMutex
 }
 
 // DeviceData is a data representation of device used for loading and saving devices.
 type DeviceData struct {
-	// Aliases represnt aliases within Device.
+	// Aliases represents aliases within Device.
 	Aliases []Alias `json:"aliases"`
-	// CurrentBan represents currentBan within Device.
-	CurrentBan Punishment `json:"current_ban"`
-	// PastBans represents pastBans within Device.
-	PastBans []Punishment `json:"past_bans"`
-	// CurrentMute represents currentMute within Device.
-	CurrentMute Punishment `json:"current_mute"`
-	// PastMutes represents pastMutes within Device.
-	PastMutes []Punishment `json:"past_mutes"`
+	// Punishments represents Punishments within Device.
+	Punishments PunishmentsData `json:"punishments"`
+
+	legacyData
 }
 
 func (d *DeviceData) Container() Container {
-	return &Device{
-		aliases:     d.Aliases,
-		currentBan:  d.CurrentBan,
-		pastBans:    d.PastBans,
-		currentMute: d.CurrentMute,
-		pastMutes:   d.PastMutes,
-	}
+	c := &Device{aliases: d.Aliases}
+	c.Punishments.load(d.Punishments, d.legacyData)
+	return c
 }
 
-// AddAlias attempts to add an alias into IP, it will return true if it managed to add it and false if a value already
+// AddAlias attempts to add an alias into Device, it will return true if it managed to add it and false if a value already
 // existed.
 func (d *Device) AddAlias(alias Alias) bool {
 	d.lock.Lock()
@@ -64,75 +50,34 @@ func (d *Device) Aliases() []Alias {
 	return d.aliases
 }
 
-// Banned returns whether the current holder is banned or not.
-func (d *Device) Banned() bool {
+// empty returns whether the Device holds no aliases and no punishments at all.
+func (d *Device) empty() bool {
 	d.lock.RLock()
 	defer d.lock.RUnlock()
-	return d.currentBan != Punishment{}
+	return len(d.aliases) == 0 && d.Punishments.empty()
 }
 
-// CurrentBan returns the users current ban.
-func (d *Device) CurrentBan() Punishment {
-	d.lock.RLock()
-	defer d.lock.RUnlock()
-	return d.currentBan
-}
-
-// Ban adds a ban to a users current ban, it moves their previous current ban to their pastBans.
-func (d *Device) Ban(b Punishment) {
+// merge merges the aliases and punishments of another Device into this one. The current punishments of the Device
+// that last longest are kept, the others are moved into the history.
+func (d *Device) merge(o *Device) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
 	d.lock.Lock()
 	defer d.lock.Unlock()
-	d.pastBans = append(d.pastBans, d.currentBan)
-	d.currentBan = b
-}
-
-// BanHistory returns the BanHistory for the user, instead of returning a ban it returns a []BanData as it's meant to
-// be used for reading bans only.
-func (d *Device) BanHistory() []Punishment {
-	d.lock.RLock()
-	defer d.lock.RUnlock()
-	return d.pastBans
-}
-
-// Muted returns whether the current holder is banned or not.
-func (d *Device) Muted() bool {
-	d.lock.RLock()
-	defer d.lock.RUnlock()
-	return d.currentBan != Punishment{}
-}
-
-// CurrentMute returns the users current ban.
-func (d *Device) CurrentMute() Punishment {
-	d.lock.RLock()
-	defer d.lock.RUnlock()
-	return d.currentBan
-}
-
-// Mute adds a ban to a users current ban, it moves their previous current ban to their pastBans.
-func (d *Device) Mute(b Punishment) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-	d.pastBans = append(d.pastBans, d.currentBan)
-	d.currentBan = b
-}
-
-// MuteHistory returns the MuteHistory for the user, instead of returning a punishment it returns a []Data as it's meant to
-// be used for reading mutes only.
-func (d *Device) MuteHistory() []Punishment {
-	d.lock.RLock()
-	defer d.lock.RUnlock()
-	return d.pastMutes
+	for _, a := range o.aliases {
+		if !slices.Contains(d.aliases, a) {
+			d.aliases = append(d.aliases, a)
+		}
+	}
+	d.Punishments.merge(&o.Punishments)
 }
 
-// Data returns the data representation of IP.
+// Data returns the data representation of Device.
 func (d *Device) Data() DataHolder {
 	d.lock.RLock()
 	defer d.lock.RUnlock()
 	return &DeviceData{
 		Aliases:     d.aliases,
-		CurrentBan:  d.currentBan,
-		PastBans:    d.pastBans,
-		CurrentMute: d.currentMute,
-		PastMutes:   d.pastMutes,
+		Punishments: d.Punishments.data(),
 	}
 }
@@ -0,0 +1,91 @@
+package punishment
+
+import (
+	"fmt"
+	"time"
+)
+
+// Step is a single step of a Ladder, describing the punishment issued for an offence.
+type Step struct {
+	// Kind is the Kind of the punishment issued.
+	Kind Kind
+	// Duration is how long the punishment lasts. Punishments are permanent if it is 0, which must be the case for
+	// Kinds that don't expire.
+	Duration time.Duration
+}
+
+// Ladder is the escalation of punishments issued for repeated offences of a single category.
+type Ladder struct {
+	// Steps are the steps of the Ladder, in order. The first step is taken for a first offence, the second for a second
+	// offence and so on. The last step is taken again for every offence after it.
+	Steps []Step
+	// Lookback is how long earlier offences count towards the next step. Every earlier offence counts if it is 0.
+	Lookback time.Duration
+}
+
+// EscalationPolicy holds the Ladder of every offence category, indexed by the name of the category.
+type EscalationPolicy map[string]Ladder
+
+// Escalation describes the step Escalate took.
+type Escalation struct {
+	// Category is the offence category escalated.
+	Category string
+	// Offences is the number of earlier offences of the category that counted towards the step.
+	Offences int
+	// Step is the index of the step taken in the Ladder of the category.
+	Step int
+	// Kind is the Kind of the punishment issued.
+	Kind Kind
+	// Punishment is the punishment issued.
+	Punishment Punishment
+}
+
+// SetEscalationPolicy sets the EscalationPolicy used by Escalate, replacing the previous one.
+func (r *Registry) SetEscalationPolicy(policy EscalationPolicy) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.escalation = policy
+}
+
+// Escalate punishes the user with the xuid passed for an offence of the category passed. The step of the Ladder of the
+// category taken is chosen by the number of punishments of the category in the record of the user that were issued
+// within the lookback window of the Ladder. Punishments that were lifted don't count. The punishment issued is
+// recorded with the category, which is also used as its reason.
+func (r *Registry) Escalate(xuid, category, issuer string) (Escalation, error) {
+	r.lock.RLock()
+	ladder, ok := r.escalation[category]
+	r.lock.RUnlock()
+	if !ok || len(ladder.Steps) == 0 {
+		return Escalation{}, fmt.Errorf("no escalation ladder for category %q", category)
+	}
+	x, err := r.Xbox(xuid)
+	if err != nil {
+		return Escalation{}, err
+	}
+	now := time.Now()
+	offences := 0
+	for _, e := range x.Record() {
+		p := e.Punishment
+		if p.Category != category || p.Lifted() {
+			continue
+		}
+		if ladder.Lookback == 0 || p.Time.After(now.Add(-ladder.Lookback)) {
+			offences++
+		}
+	}
+	step := offences
+	if step >= len(ladder.Steps) {
+		step = len(ladder.Steps) - 1
+	}
+	s := ladder.Steps[step]
+	p := NewPunishment(category, issuer)
+	if s.Duration > 0 {
+		p = NewTemporary(s.Duration, category, issuer)
+	}
+	p.Category = category
+	p, err = r.punish(XuidKey(xuid), x, s.Kind, p)
+	if err != nil {
+		return Escalation{}, err
+	}
+	return Escalation{Category: category, Offences: offences, Step: step, Kind: s.Kind, Punishment: p}, nil
+}
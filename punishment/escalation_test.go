@@ -0,0 +1,62 @@
+package punishment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscalate(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	r.SetEscalationPolicy(EscalationPolicy{"chat": {
+		Steps: []Step{
+			{Kind: KindWarning},
+			{Kind: KindMute, Duration: time.Hour},
+			{Kind: KindMute, Duration: 7 * 24 * time.Hour},
+		},
+		Lookback: 30 * 24 * time.Hour,
+	}})
+	if _, err := r.Escalate("xuid", "hacking", "staff"); err == nil {
+		t.Fatalf("expected error for category without ladder")
+	}
+
+	want := []struct {
+		kind     Kind
+		duration time.Duration
+	}{{KindWarning, 0}, {KindMute, time.Hour}, {KindMute, 7 * 24 * time.Hour}, {KindMute, 7 * 24 * time.Hour}}
+	for i, w := range want {
+		e, err := r.Escalate("xuid", "chat", "staff")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.Offences != i || e.Kind != w.kind || e.Punishment.Category != "chat" {
+			t.Fatalf("offence %v: unexpected escalation %+v", i, e)
+		}
+		if got := e.Punishment.ExpirationTime.Sub(e.Punishment.Time); e.Punishment.Expires && got != w.duration {
+			t.Fatalf("offence %v: expected duration %v, got %v", i, w.duration, got)
+		}
+	}
+}
+
+func TestEscalateIgnoresOldAndLiftedOffences(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	r.SetEscalationPolicy(EscalationPolicy{"chat": {
+		Steps:    []Step{{Kind: KindWarning}, {Kind: KindMute, Duration: time.Hour}},
+		Lookback: 24 * time.Hour,
+	}})
+	x, _ := r.Xbox("xuid")
+	old := NewPunishment("chat", "staff")
+	old.Time, old.Category = time.Now().Add(-48*time.Hour), "chat"
+	x.Warn(old)
+	lifted := NewTemporary(time.Hour, "chat", "staff")
+	lifted.Category = "chat"
+	x.Mute(lifted)
+	_ = x.Unmute("admin", "appeal")
+
+	e, err := r.Escalate("xuid", "chat", "staff")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Offences != 0 || e.Kind != KindWarning {
+		t.Fatalf("expected old and lifted offences not to count, got %+v", e)
+	}
+}
@@ -0,0 +1,138 @@
+package punishment
+
+import (
+	"fmt"
+	"time"
+)
+
+// EvasionAction is the action a Registry takes when it detects ban evasion.
+type EvasionAction int
+
+const (
+	// EvasionAlert only reports ban evasion to the EvasionHandler.
+	EvasionAlert EvasionAction = iota
+	// EvasionBan bans the new account with a reason linking it to the ban evaded. The new ban ends when the evaded ban
+	// does.
+	EvasionBan
+	// EvasionExtend extends the ban evaded by the Extension of the EvasionPolicy.
+	EvasionExtend
+)
+
+// EvasionPolicy configures how a Registry handles ban evasion.
+type EvasionPolicy struct {
+	// Action is the action taken when ban evasion is detected.
+	Action EvasionAction
+	// Issuer is the issuer recorded on bans issued because of ban evasion.
+	Issuer string
+	// Extension is how long the ban evaded is extended by when Action is EvasionExtend.
+	Extension time.Duration
+	// LinkedAccounts also treats joining on an ip address or device that a banned account was seen on as evading the
+	// ban of that account. It is off by default, as ip addresses are often shared by many unrelated players, such as
+	// behind carrier-grade NAT, who would all be flagged.
+	LinkedAccounts bool
+}
+
+// Evasion describes an account found evading a ban, by joining on an ip address or device that is banned or, if the
+// EvasionPolicy has LinkedAccounts set, that a banned account was seen on.
+type Evasion struct {
+	// Username and Xuid identify the account evading the ban.
+	Username, Xuid string
+	// Shared is the Key of the ip address or device the account shares with the ban evaded.
+	Shared Key
+	// Evaded is the Key of the container holding the ban evaded. This is either Shared itself, or the xbox of another
+	// account seen on it if the EvasionPolicy has LinkedAccounts set.
+	Evaded Key
+	// Ban is the ban evaded.
+	Ban Punishment
+	// Action is the action that was taken.
+	Action EvasionAction
+}
+
+// EvasionHandler is called for every ban evasion detected by a Registry.
+type EvasionHandler func(e Evasion)
+
+// DetectEvasion enables ban evasion detection in AddAlias. Whenever an account is seen on an ip address or device
+// for the first time, the ip address or device is checked for an active ban, as are the other accounts seen on it if
+// the policy has LinkedAccounts set. The policy passed decides what happens if one is found, and handler, which may
+// be nil, is called for every evasion.
+func (r *Registry) DetectEvasion(policy EvasionPolicy, handler EvasionHandler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.evasionPolicy = &policy
+	r.evasionHandler = handler
+}
+
+// aliasHolder is an ip or device container an alias was added to.
+type aliasHolder struct {
+	key       Key
+	container interface {
+		Punishable
+		Aliases() []Alias
+	}
+}
+
+// detectEvasion checks the ip and device containers passed, which the xuid passed was just seen on for the first
+// time, for ban evasion.
+func (r *Registry) detectEvasion(username, xuid string, holders []aliasHolder) {
+	r.lock.RLock()
+	policy, handler := r.evasionPolicy, r.evasionHandler
+	r.lock.RUnlock()
+	if policy == nil {
+		return
+	}
+	x, err := r.Xbox(xuid)
+	if err != nil || x.Banned() {
+		// Accounts that are banned themselves aren't evading anything.
+		return
+	}
+	for _, h := range holders {
+		e, ok := r.findEvasion(xuid, h, policy.LinkedAccounts)
+		if !ok {
+			continue
+		}
+		e.Username, e.Action = username, policy.Action
+		switch policy.Action {
+		case EvasionBan:
+			if !x.Banned() {
+				ban := NewPunishment(fmt.Sprintf("Ban evasion (%v)", e.Evaded), policy.Issuer)
+				ban.Expires, ban.ExpirationTime = e.Ban.Expires, e.Ban.ExpirationTime
+				_, _ = r.punish(XuidKey(xuid), x, KindBan, ban)
+			}
+		case EvasionExtend:
+			if c, err := r.Load(e.Evaded); err == nil {
+				if p, ok := c.(Punishable); ok {
+					if extended, err := p.Extend(KindBan, policy.Extension); err == nil {
+						e.Ban = extended
+					}
+				}
+			}
+		}
+		if handler != nil {
+			handler(e)
+		}
+	}
+}
+
+// findEvasion looks for an active ban on the container passed, or on any other account seen on it if linked is true.
+func (r *Registry) findEvasion(xuid string, h aliasHolder, linked bool) (Evasion, bool) {
+	e := Evasion{Xuid: xuid, Shared: h.key}
+	if h.container.Punished(KindBan) {
+		e.Evaded, e.Ban = h.key, h.container.Current(KindBan)
+		return e, true
+	}
+	if !linked {
+		return e, false
+	}
+	for _, a := range h.container.Aliases() {
+		if a.Xuid == xuid || a.Xuid == "" {
+			continue
+		}
+		other, err := r.Xbox(a.Xuid)
+		if err != nil || !other.Banned() {
+			continue
+		}
+		e.Evaded, e.Ban = XuidKey(a.Xuid), other.CurrentBan()
+		return e, true
+	}
+	return e, false
+}
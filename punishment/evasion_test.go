@@ -0,0 +1,71 @@
+package punishment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvasionBan(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var evasions []Evasion
+	r.DetectEvasion(EvasionPolicy{Action: EvasionBan, Issuer: "console", LinkedAccounts: true}, func(e Evasion) {
+		evasions = append(evasions, e)
+	})
+	r.AddAlias("main", "1.1.1.1", "device", "main")
+	x, _ := r.Xbox("main")
+	ban := NewTemporary(time.Hour, "hacking", "staff")
+	x.Ban(ban)
+
+	r.AddAlias("alt", "2.2.2.2", "device", "alt")
+	if len(evasions) != 1 {
+		t.Fatalf("expected one evasion, got %v", evasions)
+	}
+	e := evasions[0]
+	if e.Xuid != "alt" || e.Shared != DeviceKey("device") || e.Evaded != XuidKey("main") {
+		t.Fatalf("unexpected evasion %+v", e)
+	}
+	alt, _ := r.Xbox("alt")
+	if !alt.Banned() || !alt.CurrentBan().ExpirationTime.Equal(ban.ExpirationTime) {
+		t.Fatalf("expected alt to be banned until the evaded ban ends, got %v", alt.CurrentBan())
+	}
+
+	r.AddAlias("alt", "2.2.2.2", "device", "alt")
+	if len(evasions) != 1 {
+		t.Fatalf("known aliases should not be reported again, got %v", evasions)
+	}
+}
+
+func TestEvasionExtend(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	r.DetectEvasion(EvasionPolicy{Action: EvasionExtend, Extension: time.Hour}, nil)
+	ip, _ := r.Ip("1.1.1.1")
+	ban := NewTemporary(time.Hour, "hacking", "staff")
+	ip.Ban(ban)
+
+	r.AddAlias("alt", "1.1.1.1", "device", "alt")
+	if want := ban.ExpirationTime.Add(time.Hour); !ip.CurrentBan().ExpirationTime.Equal(want) {
+		t.Fatalf("expected ip ban to be extended to %v, got %v", want, ip.CurrentBan().ExpirationTime)
+	}
+	if alt, _ := r.Xbox("alt"); alt.Banned() {
+		t.Fatalf("extending should not ban the new account")
+	}
+}
+
+func TestEvasionIgnoresLinkedAccountsByDefault(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var evasions []Evasion
+	r.DetectEvasion(EvasionPolicy{Action: EvasionBan, Issuer: "console"}, func(e Evasion) {
+		evasions = append(evasions, e)
+	})
+	r.AddAlias("main", "1.1.1.1", "device", "main")
+	x, _ := r.Xbox("main")
+	x.Ban(NewTemporary(time.Hour, "hacking", "staff"))
+
+	r.AddAlias("neighbour", "1.1.1.1", "other-device", "neighbour")
+	if len(evasions) != 0 {
+		t.Fatalf("expected sharing an ip with a banned account not to count as evasion, got %v", evasions)
+	}
+	if n, _ := r.Xbox("neighbour"); n.Banned() {
+		t.Fatalf("expected the new account not to be banned")
+	}
+}
@@ -0,0 +1,198 @@
+package punishment
+
+import (
+	"net/netip"
+	"sync"
+)
+
+// EventType is the type of an Event emitted by a Registry.
+type EventType int
+
+const (
+	// EventIssued is emitted when a punishment is issued through a Registry.
+	EventIssued EventType = iota
+	// EventLifted is emitted when a punishment is lifted through a Registry.
+	EventLifted
+	// EventExpired is emitted when a Scheduler moves an expired punishment into the history.
+	EventExpired
+	// EventAliasAdded is emitted when AddAlias adds an alias to an ip or device it wasn't seen on before.
+	EventAliasAdded
+	// EventLoaded is emitted when a container is loaded from the provider.
+	EventLoaded
+	// EventSaved is emitted when a container is saved to the provider.
+	EventSaved
+	// EventShortened is emitted when a punishment is shortened through a Registry, such as when an appeal against it
+	// is reduced. Punishment holds the punishment with its new expiration time.
+	EventShortened
+)
+
+// String ...
+func (t EventType) String() string {
+	switch t {
+	case EventIssued:
+		return "issued"
+	case EventLifted:
+		return "lifted"
+	case EventExpired:
+		return "expired"
+	case EventAliasAdded:
+		return "alias_added"
+	case EventLoaded:
+		return "loaded"
+	case EventSaved:
+		return "saved"
+	case EventShortened:
+		return "shortened"
+	}
+	return "unknown"
+}
+
+// Event is an event emitted by a Registry.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+	// Key is the Key of the container the event happened on, holding its type and identifier.
+	Key Key
+	// Range is the CIDR block of the range ban the event is about if Key is that of the Ranges container.
+	Range netip.Prefix
+	// Kind is the Kind of the punishment the event is about. It is empty for events that aren't about a punishment.
+	Kind Kind
+	// Punishment is the punishment the event is about. It is empty for events that aren't about a punishment.
+	Punishment Punishment
+	// Alias is the alias added for EventAliasAdded events.
+	Alias Alias
+}
+
+// Listener is called for every Event a Registry emits that it subscribed to.
+type Listener func(e Event)
+
+// subscription is a Listener subscribed to a Registry, along with the types of events it subscribed to.
+type subscription struct {
+	id       int
+	listener Listener
+	// types holds the types of events the Listener is called for, or nil if it is called for all events.
+	types map[EventType]struct{}
+}
+
+// dispatcher calls the listeners subscribed to a Registry. Events are queued and delivered in order on a separate
+// goroutine, so listeners never run while the Registry is locked and may call back into the Registry.
+type dispatcher struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// subscriptions holds every subscription in the order they subscribed, by an ID so that they can be unsubscribed.
+	subscriptions []subscription
+	next          int
+	// queue holds the events that weren't delivered yet.
+	queue []Event
+	// busy is true while the dispatcher goroutine is delivering an event.
+	busy bool
+	// running is true once the dispatcher goroutine has started, and closed is true once it is told to stop.
+	running, closed bool
+}
+
+// newDispatcher returns a new dispatcher without subscriptions. Its goroutine is started on the first subscription.
+func newDispatcher() *dispatcher {
+	d := &dispatcher{}
+	d.cond = sync.NewCond(&d.mu)
+	return d
+}
+
+// Subscribe subscribes the Listener passed to the events of the types passed, or to all events if no types are passed.
+// Listeners are called one event at a time, in the order the events were emitted, on a goroutine of the Registry
+// rather than the one that caused the event. This means listeners may freely call into the Registry, but a slow
+// Listener delays the delivery of later events to every Listener. The function returned unsubscribes the Listener.
+func (r *Registry) Subscribe(l Listener, types ...EventType) (unsubscribe func()) {
+	s := subscription{listener: l}
+	if len(types) > 0 {
+		s.types = make(map[EventType]struct{}, len(types))
+		for _, t := range types {
+			s.types[t] = struct{}{}
+		}
+	}
+	d := r.events
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s.id = d.next
+	d.next++
+	d.subscriptions = append(d.subscriptions, s)
+	if !d.running && !d.closed {
+		d.running = true
+		go d.run()
+	}
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for i, o := range d.subscriptions {
+			if o.id == s.id {
+				d.subscriptions = append(d.subscriptions[:i:i], d.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit queues the Event passed for delivery to the listeners subscribed. It never blocks on listeners, so it may be
+// called while the Registry is locked.
+func (r *Registry) emit(e Event) {
+	d := r.events
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.subscriptions) == 0 || d.closed {
+		return
+	}
+	d.queue = append(d.queue, e)
+	d.cond.Broadcast()
+}
+
+// run delivers queued events until the dispatcher is closed and its queue is empty.
+func (d *dispatcher) run() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for {
+		for len(d.queue) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.queue) == 0 {
+			d.running = false
+			d.cond.Broadcast()
+			return
+		}
+		e := d.queue[0]
+		d.queue = d.queue[1:]
+		var listeners []Listener
+		for _, s := range d.subscriptions {
+			if _, ok := s.types[e.Type]; s.types == nil || ok {
+				listeners = append(listeners, s.listener)
+			}
+		}
+		d.busy = true
+		d.mu.Unlock()
+		for _, l := range listeners {
+			l(e)
+		}
+		d.mu.Lock()
+		d.busy = false
+		d.cond.Broadcast()
+	}
+}
+
+// wait blocks until every event queued so far has been delivered.
+func (d *dispatcher) wait() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for d.running && (len(d.queue) > 0 || d.busy) {
+		d.cond.Wait()
+	}
+}
+
+// close delivers the events still queued and stops the dispatcher. Events emitted afterwards are dropped. It must not
+// be called from a Listener.
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	d.cond.Broadcast()
+	for d.running {
+		d.cond.Wait()
+	}
+}
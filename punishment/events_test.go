@@ -0,0 +1,168 @@
+package punishment
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var events []Event
+	unsubscribe := r.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	var issued []Event
+	r.Subscribe(func(e Event) {
+		issued = append(issued, e)
+	}, EventIssued)
+
+	ban := NewTemporary(time.Hour, "hacking", "staff")
+	ban, _ = r.Punish(XuidKey("xuid"), KindBan, ban)
+	_ = r.Unban(XuidKey("xuid"), "admin", "appeal")
+	r.AddAlias("user", "1.2.3.4", "", "xuid")
+	_ = r.Save()
+	r.events.wait()
+
+	want := []EventType{EventLoaded, EventIssued, EventLifted, EventLoaded, EventAliasAdded}
+	if len(events) < len(want) {
+		t.Fatalf("expected at least %v events, got %v", len(want), events)
+	}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Fatalf("event %v: expected %v, got %v", i, typ, events[i].Type)
+		}
+	}
+	if events[1].Key != XuidKey("xuid") || events[1].Kind != KindBan || events[1].Punishment.ID != ban.ID {
+		t.Fatalf("unexpected issued event %+v", events[1])
+	}
+	if !events[2].Punishment.Lifted() {
+		t.Fatalf("expected lifted event to hold the lifted punishment")
+	}
+	if events[4].Key != IpKey("1.2.3.4") || events[4].Alias.Xuid != "xuid" {
+		t.Fatalf("unexpected alias event %+v", events[4])
+	}
+	if last := events[len(events)-1]; last.Type != EventSaved {
+		t.Fatalf("expected saved events last, got %v", last.Type)
+	}
+	if len(issued) != 1 {
+		t.Fatalf("expected filtered listener to only receive issued events, got %v", issued)
+	}
+
+	unsubscribe()
+	n := len(events)
+	_, _ = r.Punish(XuidKey("xuid"), KindMute, NewPunishment("spam", "staff"))
+	r.events.wait()
+	if len(events) != n || len(issued) != 2 {
+		t.Fatalf("expected unsubscribed listener not to be called")
+	}
+	_ = r.Close()
+}
+
+func TestListenersCanCallRegistry(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	done := make(chan struct{})
+	r.Subscribe(func(e Event) {
+		// Calling back into the Registry from a listener must not deadlock.
+		x, _ := r.Xbox(e.Key.ID)
+		_ = x.Banned()
+		_ = r.Save()
+		close(done)
+	}, EventIssued)
+	_, _ = r.Punish(XuidKey("xuid"), KindBan, NewPunishment("hacking", "staff"))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("listener deadlocked")
+	}
+	_ = r.Close()
+}
+
+func TestSchedulerEmitsExpiredEvents(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var expired []Event
+	r.Subscribe(func(e Event) {
+		expired = append(expired, e)
+	}, EventExpired)
+	_, _ = r.Punish(XuidKey("xuid"), KindMute, NewTemporary(time.Minute, "spam", "staff"))
+	now := time.Now().Add(time.Hour)
+	s, err := NewScheduler(&r, time.Second, func() time.Time { return now }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Tick()
+	_ = r.Close()
+	if len(expired) != 1 || expired[0].Kind != KindMute || expired[0].Key != XuidKey("xuid") {
+		t.Fatalf("expected an expired event for the mute, got %+v", expired)
+	}
+}
+
+func TestRangeEvents(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var events []Event
+	r.Subscribe(func(e Event) {
+		events = append(events, e)
+	}, EventIssued, EventLifted)
+
+	first, _ := r.BanRange("10.0.0.0/8", NewPunishment("proxy", "staff"))
+	_ = r.UnbanRange("10.0.0.0/8", "admin", "mistake")
+	second, _ := r.BanRange("192.168.0.1/16", NewPunishment("proxy", "staff"))
+	_ = r.LiftCase(second.ID, "admin", "appeal")
+	r.events.wait()
+
+	want := []struct {
+		typ    EventType
+		prefix netip.Prefix
+		id     string
+	}{
+		{EventIssued, netip.MustParsePrefix("10.0.0.0/8"), first.ID},
+		{EventLifted, netip.MustParsePrefix("10.0.0.0/8"), first.ID},
+		{EventIssued, netip.MustParsePrefix("192.168.0.0/16"), second.ID},
+		{EventLifted, netip.MustParsePrefix("192.168.0.0/16"), second.ID},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Type != w.typ || e.Key != rangesKey || e.Range != w.prefix || e.Kind != KindBan || e.Punishment.ID != w.id {
+			t.Fatalf("event %v: expected %v of %v on %v, got %+v", i, w.typ, w.id, w.prefix, e)
+		}
+		if c, _ := r.Case(e.Punishment.ID); c.Key != e.Key {
+			t.Fatalf("event %v: expected the key of the case %v, got %v", i, c.Key, e.Key)
+		}
+		if e.Type == EventLifted && !e.Punishment.Lifted() {
+			t.Fatalf("expected lifted event to hold the lifted ban")
+		}
+	}
+	_ = r.Close()
+}
+
+func TestReduceAppealEmitsShortened(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var events []Event
+	r.Subscribe(func(e Event) {
+		events = append(events, e)
+	}, EventShortened)
+
+	ban, _ := r.Punish(XuidKey("xuid"), KindBan, NewPunishment("hacking", "staff"))
+	if _, err := r.OpenAppeal(ban.ID, "player", "it was my brother"); err != nil {
+		t.Fatal(err)
+	}
+	expiration := time.Now().Add(time.Hour)
+	if _, err := r.ReduceAppeal(ban.ID, "admin", "first offence", expiration); err != nil {
+		t.Fatal(err)
+	}
+	r.events.wait()
+	if len(events) != 1 {
+		t.Fatalf("expected a single shortened event, got %+v", events)
+	}
+	e := events[0]
+	if e.Key != XuidKey("xuid") || e.Kind != KindBan || e.Punishment.ID != ban.ID {
+		t.Fatalf("unexpected shortened event %+v", e)
+	}
+	if !e.Punishment.ExpirationTime.Equal(expiration) {
+		t.Fatalf("expected event to hold the new expiration time, got %v", e.Punishment.ExpirationTime)
+	}
+	_ = r.Close()
+}
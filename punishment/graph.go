@@ -0,0 +1,88 @@
+package punishment
+
+import "fmt"
+
+// Link links two accounts that have been seen on the same ip address or device.
+type Link struct {
+	// From is the xuid of the account the link was found from.
+	From string
+	// To is the xuid of the account linked to From.
+	To string
+	// Via is the Key of the ip address or device shared by both accounts.
+	Via Key
+}
+
+// Graph holds the accounts linked to an account through shared ip addresses and devices.
+type Graph struct {
+	// Root is the xuid of the account the Graph was built from.
+	Root string
+	// Accounts maps the xuid of every account found, including Root, to the number of links between it and Root.
+	Accounts map[string]int
+	// Links holds the links between the accounts found. Every shared identifier is only walked once, so accounts that
+	// share an identifier are linked to the account that identifier was found from, not to each other.
+	Links []Link
+}
+
+// Linked walks the ip addresses and devices the account passed has been seen on, the accounts seen on those, their ip
+// addresses and devices and so on, returning every account found at most depth links away.
+func (r *Registry) Linked(xuid string, depth int) (Graph, error) {
+	g := Graph{Root: xuid, Accounts: map[string]int{xuid: 0}}
+	walked := map[Key]struct{}{}
+
+	queue := []string{xuid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if g.Accounts[current] >= depth {
+			continue
+		}
+		x, err := r.Xbox(current)
+		if err != nil {
+			return Graph{}, err
+		}
+		var shared []Key
+		for _, ip := range x.Ips() {
+			shared = append(shared, IpKey(ip))
+		}
+		for _, device := range x.Devices() {
+			shared = append(shared, DeviceKey(device))
+		}
+		for _, k := range shared {
+			if _, ok := walked[k]; ok {
+				continue
+			}
+			walked[k] = struct{}{}
+
+			aliases, err := r.aliases(k)
+			if err != nil {
+				return Graph{}, err
+			}
+			for _, a := range aliases {
+				if a.Xuid == current || a.Xuid == "" {
+					continue
+				}
+				g.Links = append(g.Links, Link{From: current, To: a.Xuid, Via: k})
+				if _, ok := g.Accounts[a.Xuid]; !ok {
+					g.Accounts[a.Xuid] = g.Accounts[current] + 1
+					queue = append(queue, a.Xuid)
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// aliases returns the aliases of the ip or device container with the Key passed.
+func (r *Registry) aliases(k Key) ([]Alias, error) {
+	c, err := r.Load(k)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := c.(interface {
+		Aliases() []Alias
+	})
+	if !ok {
+		return nil, fmt.Errorf("container type %T doesn't hold aliases", c)
+	}
+	return a.Aliases(), nil
+}
@@ -0,0 +1,40 @@
+package punishment
+
+import "testing"
+
+func TestLinked(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	r.AddAlias("main", "1.1.1.1", "device-a", "main")
+	r.AddAlias("alt", "1.1.1.1", "device-b", "alt")
+	r.AddAlias("alt2", "2.2.2.2", "device-b", "alt2")
+	r.AddAlias("unrelated", "3.3.3.3", "device-c", "unrelated")
+
+	g, err := r.Linked("main", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Accounts) != 2 || g.Accounts["alt"] != 1 {
+		t.Fatalf("expected main and alt at depth 1, got %v", g.Accounts)
+	}
+
+	g, err = r.Linked("main", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Accounts) != 3 || g.Accounts["alt2"] != 2 {
+		t.Fatalf("expected alt2 to be reached through alt, got %v", g.Accounts)
+	}
+	if _, ok := g.Accounts["unrelated"]; ok {
+		t.Fatalf("unrelated account should not be linked")
+	}
+	want := Link{From: "alt", To: "alt2", Via: DeviceKey("device-b")}
+	found := false
+	for _, l := range g.Links {
+		if l == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected link %v, got %v", want, g.Links)
+	}
+}
@@ -0,0 +1,208 @@
+package jsonprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cylex-pe/core/punishment"
+)
+
+// Provider is a punishment.Provider that stores containers as JSON files on disk. Every punishment type gets its own
+// directory, inside of which each identifier is stored in its own file.
+type Provider struct {
+	// dir is the root directory all punishment types are stored in.
+	dir string
+}
+
+// New returns a new Provider that stores its data within dir. The directory is created if it doesn't exist yet.
+func New(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create provider directory: %w", err)
+	}
+	return &Provider{dir: dir}, nil
+}
+
+// Load loads the container stored by the Key passed. If nothing has been stored for the Key yet, an empty container
+// is returned.
+func (p *Provider) Load(key punishment.Key) (punishment.Container, error) {
+	data, ok := punishment.NewDataHolder(key.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown punishment type %v", key.Type)
+	}
+	path, err := p.path(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		c, _ := punishment.NewContainer(key.Type)
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %v: %w", path, err)
+	}
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, fmt.Errorf("unable to decode %v: %w", path, err)
+	}
+	return data.Container(), nil
+}
+
+// Save writes the data passed to disk. The data is first written to a temporary file which is then renamed over the
+// old file, so that a crash halfway through never leaves a partially written file behind.
+func (p *Provider) Save(key punishment.Key, data punishment.DataHolder) error {
+	path, err := p.path(key)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return fmt.Errorf("unable to encode %v: %w", path, err)
+	}
+	return writeFile(path, b)
+}
+
+// Delete removes the file stored for the Key passed. Deleting a Key that isn't stored is not an error.
+func (p *Provider) Delete(key punishment.Key) error {
+	path, err := p.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to delete %v: %w", path, err)
+	}
+	return nil
+}
+
+// ForEach calls f for every container stored for a punishment type, in order of their file names. Iteration stops as
+// soon as f returns an error, which is then returned by ForEach.
+func (p *Provider) ForEach(ptype string, f func(key punishment.Key, c punishment.Container) error) error {
+	if _, ok := punishment.NewDataHolder(ptype); !ok {
+		return fmt.Errorf("unknown punishment type %v", ptype)
+	}
+	entries, err := os.ReadDir(filepath.Join(p.dir, escape(ptype)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to list %v: %w", ptype, err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".tmp-") {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		key := punishment.Key{Type: ptype, ID: id}
+		c, err := p.Load(key)
+		if err != nil {
+			return err
+		}
+		if err := f(key, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Index records the Key of the container the punishment with the case ID passed is recorded in. The index is stored in
+// the .cases directory, with a file for every case ID holding the Key.
+func (p *Provider) Index(id string, key punishment.Key) error {
+	path, err := p.casePath(id)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("unable to encode %v: %w", key, err)
+	}
+	return writeFile(path, b)
+}
+
+// Lookup returns the Key of the container the punishment with the case ID passed is recorded in, and false if the
+// case ID isn't indexed.
+func (p *Provider) Lookup(id string) (punishment.Key, bool, error) {
+	path, err := p.casePath(id)
+	if err != nil {
+		return punishment.Key{}, false, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return punishment.Key{}, false, nil
+	}
+	if err != nil {
+		return punishment.Key{}, false, fmt.Errorf("unable to read %v: %w", path, err)
+	}
+	var key punishment.Key
+	if err := json.Unmarshal(b, &key); err != nil {
+		return punishment.Key{}, false, fmt.Errorf("unable to decode %v: %w", path, err)
+	}
+	return key, true, nil
+}
+
+// casePath returns the path of the file that the Key of the container of the case ID passed is stored in.
+func (p *Provider) casePath(id string) (string, error) {
+	name := escape(id)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid case id %q", id)
+	}
+	return filepath.Join(p.dir, ".cases", name), nil
+}
+
+// path returns the path of the file that the Key passed is stored in.
+func (p *Provider) path(key punishment.Key) (string, error) {
+	if err := key.Validate(); err != nil {
+		return "", err
+	}
+	name := escape(key.ID)
+	if name == "." || name == ".." {
+		return "", fmt.Errorf("invalid identifier %q", name)
+	}
+	return filepath.Join(p.dir, escape(key.Type), name+".json"), nil
+}
+
+// escape escapes the name passed for use as a file name. Unlike url.PathEscape, colons are escaped too, as they can't
+// be used in file names on Windows and are common in IPv6 addresses. Names are unescaped using url.PathUnescape.
+func escape(name string) string {
+	return strings.ReplaceAll(url.PathEscape(name), ":", "%3A")
+}
+
+// writeFile atomically writes b to the path passed by writing to a temporary file first and renaming it afterwards.
+func writeFile(path string, b []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create directory %v: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	tmp := f.Name()
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to write %v: %w", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to sync %v: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to close %v: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to replace %v: %w", path, err)
+	}
+	return nil
+}
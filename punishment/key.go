@@ -0,0 +1,54 @@
+package punishment
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Key identifies a container: the punishment type of the container, such as XuidIdentifier, and the identifier of
+// the user within that type, such as their xuid.
+type Key struct {
+	// Type is the punishment type of the container.
+	Type string `json:"type"`
+	// ID is the identifier of the container within its punishment type.
+	ID string `json:"id"`
+}
+
+// XuidKey returns the Key of the Xbox container of the xuid passed.
+func XuidKey(xuid string) Key {
+	return Key{Type: XuidIdentifier, ID: xuid}
+}
+
+// IpKey returns the Key of the Ip container of the ip address passed.
+func IpKey(ip string) Key {
+	return Key{Type: IpIdentifier, ID: ip}
+}
+
+// DeviceKey returns the Key of the Device container of the device-id passed.
+func DeviceKey(device string) Key {
+	return Key{Type: DeviceIdentifier, ID: device}
+}
+
+// rangesKey is the Key of the single Ranges container.
+var rangesKey = Key{Type: RangeIdentifier, ID: "ranges"}
+
+// Validate returns an error if the punishment type of the Key is unknown or if its ID is empty or holds control
+// characters.
+func (k Key) Validate() error {
+	if _, ok := NewDataHolder(k.Type); !ok {
+		return fmt.Errorf("unknown punishment type %q", k.Type)
+	}
+	if k.ID == "" {
+		return fmt.Errorf("empty %v identifier", k.Type)
+	}
+	if strings.IndexFunc(k.ID, unicode.IsControl) != -1 {
+		return fmt.Errorf("invalid %v identifier %q", k.Type, k.ID)
+	}
+	return nil
+}
+
+// String returns the Key in the form "type:id".
+func (k Key) String() string {
+	return k.Type + ":" + k.ID
+}
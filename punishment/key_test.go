@@ -0,0 +1,29 @@
+package punishment
+
+import "testing"
+
+func TestKeyValidate(t *testing.T) {
+	for _, k := range []Key{XuidKey("2535"), IpKey("1.2.3.4"), DeviceKey("device")} {
+		if err := k.Validate(); err != nil {
+			t.Fatalf("%v: %v", k, err)
+		}
+	}
+	for _, k := range []Key{XuidKey(""), {Type: "unknown", ID: "id"}, DeviceKey("bad\x00id")} {
+		if err := k.Validate(); err == nil {
+			t.Fatalf("expected %q to be invalid", k)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidKeys(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	if _, err := r.Load(Key{Type: "unknown", ID: "id"}); err == nil {
+		t.Fatalf("expected unknown punishment type to be rejected")
+	}
+	if _, err := r.Xbox(""); err == nil {
+		t.Fatalf("expected empty xuid to be rejected")
+	}
+	if len(r.loaded()) != 0 {
+		t.Fatalf("expected nothing to be cached, got %v", r.loaded())
+	}
+}
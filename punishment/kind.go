@@ -0,0 +1,96 @@
+package punishment
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Kind is the kind of punishment a Punishment is used as, such as a ban or a mute. Every Kind must be registered
+// using RegisterKind before punishments of it can be issued.
+type Kind string
+
+const (
+	// KindBan is the Kind of punishments that prevent a player from joining.
+	KindBan Kind = "ban"
+	// KindMute is the Kind of punishments that prevent a player from chatting.
+	KindMute Kind = "mute"
+	// KindWarning is the Kind of warnings, which are only recorded.
+	KindWarning Kind = "warning"
+	// KindKick is the Kind of kicks, which remove a player from the server once.
+	KindKick Kind = "kick"
+	// KindFreeze is the Kind of punishments that prevent a player from moving.
+	KindFreeze Kind = "freeze"
+	// KindChatRestrict is the Kind of punishments that limit what a player may say in chat, such as slowing down
+	// their messages. Servers decide what a restriction means.
+	KindChatRestrict Kind = "chat_restrict"
+)
+
+// KindProperties describes how punishments of a Kind behave.
+type KindProperties struct {
+	// Stacks is true if more than one punishment of the Kind may be active at once. If false, issuing a punishment
+	// replaces the current one, which is moved into the history.
+	Stacks bool
+	// Expires is true if punishments of the Kind may expire. Punishments with an expiration time can't be issued as
+	// a Kind that doesn't expire.
+	Expires bool
+	// Instant is true if punishments of the Kind are never active and go straight into the history, such as warnings
+	// and kicks.
+	Instant bool
+	// BlocksLogin is true if active punishments of the Kind prevent a player from joining.
+	BlocksLogin bool
+	// BlocksChat is true if active punishments of the Kind prevent a player from chatting.
+	BlocksChat bool
+}
+
+var (
+	// kindsMu guards kinds.
+	kindsMu sync.RWMutex
+	// kinds holds the properties of every registered Kind.
+	kinds = map[Kind]KindProperties{}
+)
+
+func init() {
+	RegisterKind(KindBan, KindProperties{Expires: true, BlocksLogin: true})
+	RegisterKind(KindMute, KindProperties{Expires: true, BlocksChat: true})
+	RegisterKind(KindWarning, KindProperties{Instant: true})
+	RegisterKind(KindKick, KindProperties{Instant: true})
+	RegisterKind(KindFreeze, KindProperties{Expires: true})
+	RegisterKind(KindChatRestrict, KindProperties{Stacks: true, Expires: true})
+}
+
+// RegisterKind registers a Kind of punishment with the properties passed. It is typically called from an init function.
+// RegisterKind panics if the Kind is empty or was already registered.
+func RegisterKind(k Kind, properties KindProperties) {
+	if k == "" {
+		panic("punishment: kind must have a name")
+	}
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+	if _, ok := kinds[k]; ok {
+		panic(fmt.Sprintf("punishment: kind %q registered twice", k))
+	}
+	kinds[k] = properties
+}
+
+// Properties returns the properties the Kind was registered with. False is returned if the Kind isn't registered.
+func (k Kind) Properties() (KindProperties, bool) {
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	p, ok := kinds[k]
+	return p, ok
+}
+
+// Kinds returns all registered Kinds, sorted by name.
+func Kinds() []Kind {
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	all := make([]Kind, 0, len(kinds))
+	for k := range kinds {
+		all = append(all, k)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i] < all[j]
+	})
+	return all
+}
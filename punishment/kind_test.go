@@ -0,0 +1,112 @@
+package punishment
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStackingKind(t *testing.T) {
+	x := &Xbox{}
+	short := NewTemporary(time.Minute, "caps", "staff")
+	long := NewTemporary(time.Hour, "spam", "staff")
+	if err := x.Punish(KindChatRestrict, short); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := x.Punish(KindChatRestrict, long); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a := x.Active(KindChatRestrict); len(a) != 2 {
+		t.Fatalf("expected both chat restrictions to be active, got %v", a)
+	}
+	if c := x.Current(KindChatRestrict); !sameIssue(c, long) {
+		t.Fatalf("expected longest chat restriction %v, got %v", long, c)
+	}
+	if err := x.Lift(KindChatRestrict, "admin", "appeal"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if x.Punished(KindChatRestrict) || len(x.History(KindChatRestrict)) != 2 {
+		t.Fatalf("expected every chat restriction to be lifted")
+	}
+	if err := x.Lift(KindChatRestrict, "admin", "appeal"); !errors.Is(err, ErrNotActive) {
+		t.Fatalf("expected ErrNotActive, got %v", err)
+	}
+}
+
+func TestInstantKind(t *testing.T) {
+	x := &Xbox{}
+	if err := x.Punish(KindWarning, NewTemporary(time.Hour, "spam", "staff")); err == nil {
+		t.Fatalf("expected warnings not to expire")
+	}
+	if err := x.Warn(NewTemporary(time.Hour, "spam", "staff")); err == nil {
+		t.Fatalf("expected Warn to report warnings that expire")
+	}
+	if err := x.Punish(KindWarning, NewPunishment("spam", "staff")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if x.Punished(KindWarning) || len(x.History(KindWarning)) != 1 {
+		t.Fatalf("expected warning to go straight into the history")
+	}
+}
+
+// kindSpectate is a custom Kind used to test registering kinds. It is registered once, so that the tests can be run
+// more than once in a single process.
+const kindSpectate Kind = "spectate_only"
+
+var registerKindSpectate sync.Once
+
+func TestCustomKind(t *testing.T) {
+	if err := (&Xbox{}).Punish("never_registered", NewPunishment("griefing", "staff")); err == nil {
+		t.Fatalf("expected unregistered kind to be refused")
+	}
+	registerKindSpectate.Do(func() { RegisterKind(kindSpectate, KindProperties{Expires: true, BlocksLogin: true}) })
+
+	p := newMemoryProvider()
+	r := New(p, nil)
+	ban, err := r.Punish(XuidKey("xuid"), kindSpectate, NewTemporary(time.Hour, "griefing", "staff"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, err := r.CheckLogin("user", "xuid", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Allowed || !reflect.DeepEqual(v.Punishment, ban) {
+		t.Fatalf("expected login to be denied by custom kind, got %+v", v)
+	}
+	if err := r.Lift(XuidKey("xuid"), kindSpectate, "admin", "appeal"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLegacyContainerData(t *testing.T) {
+	ban := NewPunishment("hacking", "staff")
+	past := NewPunishment("spam", "staff")
+	legacy, _ := json.Marshal(map[string]any{
+		"current_ban":  ban,
+		"past_mutes":   []Punishment{past},
+		"warnings":     []Punishment{past},
+		"current_mute": Punishment{},
+	})
+	var d XboxData
+	if err := json.Unmarshal(legacy, &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	x := d.Container().(*Xbox)
+	if x.CurrentBan().Reason() != ban.Reason() || x.Muted() {
+		t.Fatalf("expected legacy ban to be loaded as current ban")
+	}
+	if len(x.MuteHistory()) != 1 || len(x.Warnings()) != 1 {
+		t.Fatalf("expected legacy history to be loaded")
+	}
+
+	out, _ := json.Marshal(x.Data())
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(out, &fields)
+	if _, ok := fields["current_ban"]; ok {
+		t.Fatalf("expected legacy fields not to be written")
+	}
+}
@@ -0,0 +1,139 @@
+package punishment
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoteNotFound is returned when editing a note that doesn't exist on a punishment.
+var ErrNoteNotFound = errors.New("note not found")
+
+// Note is a note staff left on a punishment, explaining why it was issued beyond its reason.
+type Note struct {
+	// ID is the unique identifier of the note.
+	ID string `json:"id"`
+	// Author is the name of the user that left the note.
+	Author string `json:"author"`
+	// Time is the time at which the note was left.
+	Time time.Time `json:"time"`
+	// Text is the current text of the note.
+	Text string `json:"text"`
+	// Edits holds every earlier version of the note, in the order they were replaced.
+	Edits []Edit `json:"edits,omitempty"`
+}
+
+// Edit is an earlier version of a Note, kept when the note is edited.
+type Edit struct {
+	// Author is the name of the user whose edit replaced this version.
+	Author string `json:"author"`
+	// Time is the time at which this version was replaced.
+	Time time.Time `json:"time"`
+	// Text is the text of the note before it was replaced.
+	Text string `json:"text"`
+}
+
+// EvidenceType is the type of evidence an Evidence refers to.
+type EvidenceType string
+
+const (
+	// EvidenceReplay refers to a recorded replay by its ID.
+	EvidenceReplay EvidenceType = "replay"
+	// EvidenceChatLog refers to an excerpt of the chat log.
+	EvidenceChatLog EvidenceType = "chat_log"
+	// EvidenceScreenshot refers to a screenshot by its path or URL.
+	EvidenceScreenshot EvidenceType = "screenshot"
+	// EvidenceOther refers to evidence of any other type.
+	EvidenceOther EvidenceType = "other"
+)
+
+// Evidence is a reference to evidence a punishment was issued on. The evidence itself is stored elsewhere.
+type Evidence struct {
+	// Type is the type of evidence referred to.
+	Type EvidenceType `json:"type"`
+	// Reference refers to the evidence, such as the ID of a replay, the excerpt of a chat log or the path of a
+	// screenshot.
+	Reference string `json:"reference"`
+	// AddedBy is the name of the user that added the evidence.
+	AddedBy string `json:"added_by"`
+	// Time is the time at which the evidence was added.
+	Time time.Time `json:"time"`
+}
+
+// AddNote leaves a note by the author passed on the punishment and returns it.
+func (p *Punishment) AddNote(author, text string) Note {
+	n := Note{ID: newCaseID(), Author: author, Time: time.Now(), Text: text}
+	p.PunishmentNotes = append(p.PunishmentNotes[:len(p.PunishmentNotes):len(p.PunishmentNotes)], n)
+	return n
+}
+
+// EditNote replaces the text of the note with the ID passed, keeping the text it replaces in the edit history of
+// the note. ErrNoteNotFound is returned if the punishment has no such note.
+func (p *Punishment) EditNote(id, author, text string) (Note, error) {
+	for i, n := range p.PunishmentNotes {
+		if n.ID != id {
+			continue
+		}
+		// The notes are copied first, so that copies of the punishment handed out earlier are left as they were.
+		notes := append([]Note(nil), p.PunishmentNotes...)
+		notes[i].Edits = append(n.Edits[:len(n.Edits):len(n.Edits)], Edit{Author: author, Time: time.Now(), Text: n.Text})
+		notes[i].Text = text
+		p.PunishmentNotes = notes
+		return notes[i], nil
+	}
+	return Note{}, ErrNoteNotFound
+}
+
+// AddEvidence adds a reference to evidence of the type passed to the punishment, added by the user passed.
+func (p *Punishment) AddEvidence(t EvidenceType, reference, by string) Evidence {
+	e := Evidence{Type: t, Reference: reference, AddedBy: by, Time: time.Now()}
+	p.Evidence = append(p.Evidence[:len(p.Evidence):len(p.Evidence)], e)
+	return e
+}
+
+// AddNote leaves a note on the punishment with the case ID passed and returns it.
+func (r *Registry) AddNote(id, author, text string) (Note, error) {
+	var n Note
+	err := r.updateCase(id, func(p *Punishment) error {
+		n = p.AddNote(author, text)
+		return nil
+	})
+	return n, err
+}
+
+// EditNote edits a note on the punishment with the case ID passed, keeping its earlier text in its edit history.
+// ErrNoteNotFound is returned if the punishment has no note with the note ID passed.
+func (r *Registry) EditNote(id, noteID, author, text string) (Note, error) {
+	var n Note
+	err := r.updateCase(id, func(p *Punishment) (err error) {
+		n, err = p.EditNote(noteID, author, text)
+		return err
+	})
+	return n, err
+}
+
+// AddEvidence adds a reference to evidence to the punishment with the case ID passed and returns it.
+func (r *Registry) AddEvidence(id string, t EvidenceType, reference, by string) (Evidence, error) {
+	var e Evidence
+	err := r.updateCase(id, func(p *Punishment) error {
+		e = p.AddEvidence(t, reference, by)
+		return nil
+	})
+	return e, err
+}
+
+// updateCase looks up the punishment with the case ID passed and updates it using f.
+func (r *Registry) updateCase(id string, f func(p *Punishment) error) error {
+	c, err := r.Case(id)
+	if err != nil {
+		return err
+	}
+	p, err := r.punishable(c.Key)
+	if err != nil {
+		return err
+	}
+	if err := p.Update(c.Punishment.ID, f); err != nil {
+		return fmt.Errorf("unable to update case %v: %w", c.Punishment.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package punishment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNotesAndEvidence(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	ban := NewTemporary(time.Hour, "hacking", "staff")
+	ban.AddEvidence(EvidenceReplay, "replay-1234", "staff")
+	ban, _ = r.Punish(XuidKey("xuid"), KindBan, ban)
+
+	n, err := r.AddNote(ban.ID, "staff", "flew over the spawn wall")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.AddEvidence(ban.ID, EvidenceScreenshot, "screenshots/1.png", "mod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before, _ := r.Case(ban.ID)
+	edited, err := r.EditNote(ban.ID, n.ID, "admin", "flew over the spawn wall twice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if edited.Text != "flew over the spawn wall twice" || len(edited.Edits) != 1 || edited.Edits[0].Text != n.Text || edited.Edits[0].Author != "admin" {
+		t.Fatalf("unexpected edited note %+v", edited)
+	}
+	if before.Punishment.Notes()[0].Text != n.Text {
+		t.Fatalf("expected earlier copies of the punishment to be left as they were")
+	}
+	if _, err := r.EditNote(ban.ID, "unknown", "admin", "text"); !errors.Is(err, ErrNoteNotFound) {
+		t.Fatalf("expected ErrNoteNotFound, got %v", err)
+	}
+
+	if err := r.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r = New(p, nil)
+	c, err := r.Case(ban.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notes := c.Punishment.Notes(); len(notes) != 1 || len(notes[0].Edits) != 1 {
+		t.Fatalf("expected notes to be persisted, got %+v", notes)
+	}
+	if len(c.Punishment.Evidence) != 2 || c.Punishment.Evidence[0].Reference != "replay-1234" {
+		t.Fatalf("expected evidence to be persisted, got %+v", c.Punishment.Evidence)
+	}
+}
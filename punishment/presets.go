@@ -0,0 +1,199 @@
+package punishment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Preset is a predefined reason punishments can be issued with, so that offences are described and grouped
+// consistently.
+type Preset struct {
+	// ID is the unique identifier of the Preset, which staff type in commands.
+	ID string
+	// Text is the text shown as reason of punishments issued with the Preset.
+	Text string
+	// Category is the offence category of the Preset.
+	Category string
+	// Kind is the Kind of punishment issued with the Preset by default.
+	Kind Kind
+	// Duration is how long punishments issued with the Preset last by default, 0 if they are permanent.
+	Duration time.Duration
+}
+
+// presetData is the representation of a Preset in a preset config file. Durations are written as accepted by
+// time.ParseDuration, or as a number of days such as "7d".
+type presetData struct {
+	ID       string `json:"id"`
+	Text     string `json:"text"`
+	Category string `json:"category"`
+	Kind     Kind   `json:"kind"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// MarshalJSON ...
+func (p Preset) MarshalJSON() ([]byte, error) {
+	d := presetData{ID: p.ID, Text: p.Text, Category: p.Category, Kind: p.Kind}
+	if p.Duration != 0 {
+		d.Duration = p.Duration.String()
+	}
+	return json.Marshal(d)
+}
+
+// UnmarshalJSON ...
+func (p *Preset) UnmarshalJSON(b []byte) error {
+	var d presetData
+	if err := json.Unmarshal(b, &d); err != nil {
+		return err
+	}
+	*p = Preset{ID: d.ID, Text: d.Text, Category: d.Category, Kind: d.Kind}
+	if d.Duration == "" {
+		return nil
+	}
+	if strings.HasSuffix(d.Duration, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(d.Duration, "d"))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q of preset %q: %w", d.Duration, d.ID, err)
+		}
+		p.Duration = time.Duration(n) * 24 * time.Hour
+		return nil
+	}
+	duration, err := time.ParseDuration(d.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q of preset %q: %w", d.Duration, d.ID, err)
+	}
+	p.Duration = duration
+	return nil
+}
+
+// Punishment returns a new punishment issued with the Preset. The reason passed is recorded as the reason of the
+// punishment, or the Text of the Preset if it is empty.
+func (p Preset) Punishment(reason, issuer string) Punishment {
+	if reason == "" {
+		reason = p.Text
+	}
+	punishment := NewPunishment(reason, issuer)
+	if p.Duration > 0 {
+		punishment = NewTemporary(p.Duration, reason, issuer)
+	}
+	punishment.Category, punishment.Preset = p.Category, p.ID
+	return punishment
+}
+
+// Presets is a catalogue of Presets, indexed by their IDs. It can't be changed once created.
+type Presets struct {
+	// presets holds every Preset, indexed by its ID in lower case.
+	presets map[string]Preset
+	// ids holds the IDs of every Preset in lower case, sorted.
+	ids []string
+}
+
+// NewPresets returns a catalogue of the Presets passed. IDs are case-insensitive and must be unique, and the Kind of
+// every Preset must be registered and expire if the Preset has a Duration. Durations may not be negative.
+func NewPresets(presets ...Preset) (*Presets, error) {
+	p := &Presets{presets: make(map[string]Preset, len(presets))}
+	for _, preset := range presets {
+		id := strings.ToLower(preset.ID)
+		if id == "" || strings.ContainsAny(id, " \t\n") {
+			return nil, fmt.Errorf("invalid preset id %q", preset.ID)
+		}
+		if _, ok := p.presets[id]; ok {
+			return nil, fmt.Errorf("duplicate preset id %q", preset.ID)
+		}
+		props, ok := preset.Kind.Properties()
+		if !ok {
+			return nil, fmt.Errorf("unknown punishment kind %q of preset %q", preset.Kind, preset.ID)
+		}
+		if preset.Duration < 0 {
+			return nil, fmt.Errorf("preset %q has negative duration %v", preset.ID, preset.Duration)
+		}
+		if preset.Duration > 0 && !props.Expires {
+			return nil, fmt.Errorf("preset %q has a duration but punishments of kind %q can't expire", preset.ID, preset.Kind)
+		}
+		p.presets[id] = preset
+		p.ids = append(p.ids, id)
+	}
+	sort.Strings(p.ids)
+	return p, nil
+}
+
+// LoadPresets loads a catalogue of Presets from the JSON config file at the path passed, which holds a list of
+// presets.
+func LoadPresets(path string) (*Presets, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read presets: %w", err)
+	}
+	var presets []Preset
+	if err := json.Unmarshal(b, &presets); err != nil {
+		return nil, fmt.Errorf("unable to decode presets: %w", err)
+	}
+	return NewPresets(presets...)
+}
+
+// Preset returns the Preset with the ID passed, which is case-insensitive.
+func (p *Presets) Preset(id string) (Preset, bool) {
+	preset, ok := p.presets[strings.ToLower(id)]
+	return preset, ok
+}
+
+// All returns every Preset, sorted by ID.
+func (p *Presets) All() []Preset {
+	return p.Complete("")
+}
+
+// Complete returns every Preset with an ID starting with the prefix passed, sorted by ID, for completing commands.
+// The prefix is case-insensitive.
+func (p *Presets) Complete(prefix string) []Preset {
+	prefix = strings.ToLower(prefix)
+	i := sort.SearchStrings(p.ids, prefix)
+	var presets []Preset
+	for ; i < len(p.ids) && strings.HasPrefix(p.ids[i], prefix); i++ {
+		presets = append(presets, p.presets[p.ids[i]])
+	}
+	return presets
+}
+
+// Category returns every Preset of the offence category passed, sorted by ID.
+func (p *Presets) Category(category string) []Preset {
+	var presets []Preset
+	for _, id := range p.ids {
+		if preset := p.presets[id]; preset.Category == category {
+			presets = append(presets, preset)
+		}
+	}
+	return presets
+}
+
+// SetPresets sets the catalogue of Presets used by PunishPreset.
+func (r *Registry) SetPresets(presets *Presets) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.presets = presets
+}
+
+// Presets returns the catalogue of Presets set using SetPresets, or nil if none was set.
+func (r *Registry) Presets() *Presets {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.presets
+}
+
+// PunishPreset issues a punishment with the Preset with the ID passed on the container with the Key passed, using the
+// default Kind and Duration of the Preset. The reason passed is recorded alongside the ID of the Preset, the Text of
+// the Preset being used if it is empty. The punishment issued is returned.
+func (r *Registry) PunishPreset(key Key, id, reason, issuer string) (Punishment, error) {
+	presets := r.Presets()
+	if presets == nil {
+		return Punishment{}, fmt.Errorf("no presets set")
+	}
+	preset, ok := presets.Preset(id)
+	if !ok {
+		return Punishment{}, fmt.Errorf("unknown preset %q", id)
+	}
+	return r.Punish(key, preset.Kind, preset.Punishment(reason, issuer))
+}
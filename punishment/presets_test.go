@@ -0,0 +1,70 @@
+package punishment
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	_ = os.WriteFile(path, []byte(`[
+		{"id": "hacking", "text": "Hacking", "category": "cheating", "kind": "ban", "duration": "30d"},
+		{"id": "hacking-perm", "text": "Hacking (repeat)", "category": "cheating", "kind": "ban"},
+		{"id": "spam", "text": "Spamming", "category": "chat", "kind": "mute", "duration": "1h"}
+	]`), 0644)
+	presets, err := LoadPresets(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hacking, ok := presets.Preset("HACKING")
+	if !ok || hacking.Duration != 30*24*time.Hour || hacking.Kind != KindBan {
+		t.Fatalf("unexpected preset %+v", hacking)
+	}
+	if c := presets.Complete("hac"); len(c) != 2 || c[0].ID != "hacking" || c[1].ID != "hacking-perm" {
+		t.Fatalf("unexpected completions %v", c)
+	}
+	if c := presets.Complete("x"); len(c) != 0 {
+		t.Fatalf("expected no completions, got %v", c)
+	}
+	if c := presets.Category("chat"); len(c) != 1 || c[0].ID != "spam" {
+		t.Fatalf("unexpected presets of category %v", c)
+	}
+}
+
+func TestNewPresetsValidates(t *testing.T) {
+	if _, err := NewPresets(Preset{ID: "a", Kind: KindBan}, Preset{ID: "A", Kind: KindBan}); err == nil {
+		t.Fatalf("expected duplicate ids to be refused")
+	}
+	if _, err := NewPresets(Preset{ID: "a", Kind: "unknown"}); err == nil {
+		t.Fatalf("expected unknown kinds to be refused")
+	}
+	if _, err := NewPresets(Preset{ID: "a", Kind: KindWarning, Duration: time.Hour}); err == nil {
+		t.Fatalf("expected durations of kinds that don't expire to be refused")
+	}
+	if _, err := NewPresets(Preset{ID: "a", Kind: KindBan, Duration: -7 * 24 * time.Hour}); err == nil {
+		t.Fatalf("expected negative durations to be refused")
+	}
+}
+
+func TestPunishPreset(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	presets, _ := NewPresets(Preset{ID: "spam", Text: "Spamming", Category: "chat", Kind: KindMute, Duration: time.Hour})
+	r.SetPresets(presets)
+	p, err := r.PunishPreset(XuidKey("xuid"), "spam", "", "staff")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Preset != "spam" || p.Category != "chat" || p.Reason() != "Spamming" || !p.Expires {
+		t.Fatalf("unexpected punishment %+v", p)
+	}
+	x, _ := r.Xbox("xuid")
+	if !reflect.DeepEqual(x.CurrentMute(), p) {
+		t.Fatalf("expected preset punishment to be issued as mute")
+	}
+	if _, err := r.PunishPreset(XuidKey("xuid"), "unknown", "", "staff"); err == nil {
+		t.Fatalf("expected unknown preset to be refused")
+	}
+}
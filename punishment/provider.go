@@ -2,9 +2,32 @@ package punishment
 
 // Provider represents a data provider for punishments. Punishment data will be loaded and saved using this provider.
 type Provider interface {
-	// LoadXbox is called to retrieve a specific Users punishment by xuid. If punishments don't exist
-	// it should return a DataHolder implementor with no punishments.
-	Load(ptype string, identifier any) (Container, error)
-	// SaveXbox is called when saving a users xbox punishment.
-	Save(ptype string, identifier any, data DataHolder) error
+	// Load is called to retrieve the container stored by a specific Key. If punishments don't exist it should return
+	// an empty container of the type of the Key.
+	Load(key Key) (Container, error)
+	// Save is called when saving the container stored by a specific Key.
+	Save(key Key, data DataHolder) error
+}
+
+// Iterator is implemented by providers that can list every container they store for a punishment type.
+type Iterator interface {
+	// ForEach calls f for every container stored for a punishment type. Iteration stops as soon as f returns an
+	// error, which is then returned by ForEach.
+	ForEach(ptype string, f func(key Key, c Container) error) error
+}
+
+// Deleter is implemented by providers that can remove stored containers.
+type Deleter interface {
+	// Delete removes the data stored by a specific Key.
+	Delete(key Key) error
+}
+
+// Indexer is implemented by providers that keep an index of the container every punishment is recorded in, by the
+// case ID of the punishment.
+type Indexer interface {
+	// Index records that the punishment with a case ID is recorded in the container stored by a specific Key.
+	Index(id string, key Key) error
+	// Lookup returns the Key of the container the punishment with a case ID is recorded in, and false if the case ID
+	// isn't indexed.
+	Lookup(id string) (Key, bool, error)
 }
@@ -0,0 +1,232 @@
+package punishment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// pseudonymPrefix is the prefix of every pseudonym, which sets them apart from raw identifiers.
+const pseudonymPrefix = "#"
+
+// pseudonymLength is the length of pseudonyms, including pseudonymPrefix.
+const pseudonymLength = len(pseudonymPrefix) + 32
+
+// SetIdentifierKeys enables pseudonymising ip and device identifiers. Instead of the raw ip address or device-id, the
+// Registry stores and looks up containers by a keyed hash of the identifier, so that bans and aliases keep working
+// while the data held by the provider holds only pseudonyms. The first key passed is used for new pseudonyms. Any
+// further keys are previous keys: containers stored by the pseudonym of a previous key are merged into the container
+// of the current key when their identifier is loaded, see also Rekey. The ips and devices recorded on an xbox are only
+// recorded by their new pseudonym once the account joins again, or once Rekey is called with them. Passing no keys
+// disables pseudonymising.
+func (r *Registry) SetIdentifierKeys(keys ...[]byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.identifierKeys = keys
+}
+
+// Pseudonym returns the pseudonym of the identifier of a punishment type with the key passed. Ip addresses should be
+// normalized first.
+func Pseudonym(key []byte, ptype, identifier string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(ptype))
+	h.Write([]byte{0})
+	h.Write([]byte(identifier))
+	return pseudonymPrefix + hex.EncodeToString(h.Sum(nil))[:pseudonymLength-len(pseudonymPrefix)]
+}
+
+// isPseudonym returns whether the identifier passed is a pseudonym.
+func isPseudonym(identifier string) bool {
+	if len(identifier) != pseudonymLength || !strings.HasPrefix(identifier, pseudonymPrefix) {
+		return false
+	}
+	_, err := hex.DecodeString(identifier[len(pseudonymPrefix):])
+	return err == nil
+}
+
+// key validates the Key passed and returns the Key the container is stored by. Ip addresses are normalized, and ip
+// addresses and device-ids are replaced by their pseudonym if identifier keys are set. Pseudonyms are returned as
+// they are, so that keys the Registry stored itself, such as the ips and devices recorded on an xbox, can be passed.
+// Identifiers sent by clients must be resolved using clientKey instead.
+func (r *Registry) key(k Key) (Key, error) {
+	return r.resolveKey(k, true)
+}
+
+// clientKey resolves the Key of an identifier sent by a client like key, except that identifiers that look like a
+// pseudonym are hashed too. Clients pick their own device-ids, so they must never choose the Key their container is
+// stored by.
+func (r *Registry) clientKey(k Key) (Key, error) {
+	return r.resolveKey(k, false)
+}
+
+// resolveKey returns the Key the container of the Key passed is stored by, returning pseudonyms as they are if
+// trusted is true.
+func (r *Registry) resolveKey(k Key, trusted bool) (Key, error) {
+	if err := k.Validate(); err != nil {
+		return Key{}, err
+	}
+	if k.Type != IpIdentifier && k.Type != DeviceIdentifier || trusted && isPseudonym(k.ID) {
+		return k, nil
+	}
+	if k.Type == IpIdentifier {
+		normalized, err := r.ipKey(k.ID)
+		if err != nil {
+			return Key{}, err
+		}
+		k.ID = normalized
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if len(r.identifierKeys) == 0 {
+		return k, nil
+	}
+	return Key{Type: k.Type, ID: Pseudonym(r.identifierKeys[0], k.Type, k.ID)}, nil
+}
+
+// previousKeys returns the Keys the containers of the raw Key passed were stored by under previous identifier keys.
+// Callers of this method should have the mutex within Registry locked.
+func (r *Registry) previousKeys(raw Key) ([]Key, error) {
+	if len(r.identifierKeys) < 2 {
+		return nil, nil
+	}
+	if raw.Type == IpIdentifier {
+		normalized, err := NormalizeIp(raw.ID, r.ipv6Bits)
+		if err != nil {
+			return nil, err
+		}
+		raw.ID = normalized
+	}
+	keys := make([]Key, 0, len(r.identifierKeys)-1)
+	for _, key := range r.identifierKeys[1:] {
+		keys = append(keys, Key{Type: raw.Type, ID: Pseudonym(key, raw.Type, raw.ID)})
+	}
+	return keys, nil
+}
+
+// migrateKeys merges the containers stored by the pseudonyms of the raw Key passed under previous identifier keys
+// into the container passed, deleting them from the provider if it implements Deleter. The number of containers
+// merged is returned. Callers of this method should have the mutex within Registry locked.
+func (r *Registry) migrateKeys(raw Key, c Container) (int, error) {
+	previous, err := r.previousKeys(raw)
+	if err != nil {
+		return 0, err
+	}
+	merged := 0
+	for _, old := range previous {
+		oc, err := r.provider.Load(old)
+		if err != nil {
+			return merged, err
+		}
+		if !merge(c, oc) {
+			continue
+		}
+		delete(r.punishments, old)
+		if d, ok := r.provider.(Deleter); ok {
+			if err := d.Delete(old); err != nil {
+				return merged, err
+			}
+		}
+		merged++
+	}
+	return merged, nil
+}
+
+// Rekey migrates the containers of the raw keys passed from the pseudonyms of previous identifier keys to the
+// pseudonym of the current key. Loading a key does the same, so Rekey is only needed to move containers of
+// identifiers that aren't seen again, such as the ip addresses recorded in server logs. The previous pseudonyms
+// recorded on xboxes are replaced by the current pseudonym too, so that Linked keeps finding the accounts seen on
+// them. Stored xboxes that aren't loaded are only updated if the provider implements Iterator. The number of
+// containers merged is returned. The Registry should be saved afterwards.
+func (r *Registry) Rekey(raw ...Key) (int, error) {
+	renamed := map[string]map[string]string{IpIdentifier: {}, DeviceIdentifier: {}}
+	merged := 0
+	for _, k := range raw {
+		if k.Type != IpIdentifier && k.Type != DeviceIdentifier {
+			return merged, fmt.Errorf("punishment type %v isn't pseudonymised", k.Type)
+		}
+		stored, err := r.key(k)
+		if err != nil {
+			return merged, err
+		}
+		if stored == k {
+			// The key is a pseudonym already, so there are no previous pseudonyms to migrate from.
+			continue
+		}
+		r.lock.Lock()
+		c, ok := r.punishments[stored]
+		if !ok {
+			c, err = r.provider.Load(stored)
+			if err != nil {
+				r.lock.Unlock()
+				return merged, err
+			}
+			r.punishments[stored] = c
+		}
+		previous, _ := r.previousKeys(k)
+		n, err := r.migrateKeys(k, c)
+		r.lock.Unlock()
+		merged += n
+		if err != nil {
+			return merged, err
+		}
+		for _, old := range previous {
+			renamed[k.Type][old.ID] = stored.ID
+		}
+	}
+	return merged, r.rekeyXboxes(renamed[IpIdentifier], renamed[DeviceIdentifier])
+}
+
+// rekeyXboxes replaces the ips and devices recorded on loaded and stored xboxes that are a key of ips or devices
+// respectively with their value. Stored xboxes are only found if the provider implements Iterator.
+func (r *Registry) rekeyXboxes(ips, devices map[string]string) error {
+	if len(ips) == 0 && len(devices) == 0 {
+		return nil
+	}
+	xuids := map[string]struct{}{}
+	r.lock.RLock()
+	for k := range r.punishments {
+		if k.Type == XuidIdentifier {
+			xuids[k.ID] = struct{}{}
+		}
+	}
+	r.lock.RUnlock()
+	if it, ok := r.provider.(Iterator); ok {
+		err := it.ForEach(XuidIdentifier, func(k Key, c Container) error {
+			if x, ok := c.(*Xbox); ok && x.references(ips, devices) {
+				xuids[k.ID] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for xuid := range xuids {
+		x, err := r.Xbox(xuid)
+		if err != nil {
+			return err
+		}
+		x.rekey(ips, devices)
+	}
+	return nil
+}
+
+// merge merges the container o into c if both are of the same type and o holds any data. It returns true if o was
+// merged.
+func merge(c, o Container) bool {
+	switch c := c.(type) {
+	case *Ip:
+		if o, ok := o.(*Ip); ok && !o.empty() {
+			c.merge(o)
+			return true
+		}
+	case *Device:
+		if o, ok := o.(*Device); ok && !o.empty() {
+			c.merge(o)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package punishment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPseudonymisedIdentifiers(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	r.SetIdentifierKeys([]byte("secret"))
+	r.AddAlias("player", "1.2.3.4:19132", "device-id", "xuid")
+	ip, _ := r.Ip("1.2.3.4")
+	ip.Ban(NewPunishment("hacking", "staff"))
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ptype := range []string{IpIdentifier, DeviceIdentifier} {
+		for identifier := range p.data[ptype] {
+			if !isPseudonym(identifier) {
+				t.Fatalf("expected only pseudonyms to be stored, got %v", identifier)
+			}
+		}
+	}
+	for _, seen := range p.data[XuidIdentifier]["xuid"].(*XboxData).Ips {
+		if strings.Contains(seen, "1.2.3.4") {
+			t.Fatalf("expected raw ip not to be stored on the xbox, got %v", seen)
+		}
+	}
+
+	r = New(p, nil)
+	r.SetIdentifierKeys([]byte("secret"))
+	if v, _ := r.CheckLogin("player", "other", "1.2.3.4", ""); v.Allowed {
+		t.Fatalf("expected the ban to apply to the raw ip")
+	}
+}
+
+func TestClientsCantPickPseudonyms(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	r.SetIdentifierKeys([]byte("secret"))
+	r.AddAlias("player", "1.2.3.4", "device-id", "xuid")
+	dev, _ := r.Device("device-id")
+	dev.Ban(NewPunishment("hacking", "staff"))
+
+	// A client sending the pseudonym of another player's device as its device-id must not end up on that device.
+	stolen := Pseudonym([]byte("secret"), DeviceIdentifier, "device-id")
+	r.AddAlias("attacker", "", stolen, "attacker")
+	if a := dev.Aliases(); len(a) != 1 || a[0].Xuid != "xuid" {
+		t.Fatalf("expected the attacker not to be recorded on the device, got %v", a)
+	}
+	x, _ := r.Xbox("attacker")
+	if d := x.Devices(); len(d) != 1 || d[0] == stolen || !isPseudonym(d[0]) {
+		t.Fatalf("expected the device-id sent to be hashed, got %v", d)
+	}
+	if v, _ := r.CheckLogin("attacker", "attacker", "", stolen); !v.Allowed {
+		t.Fatalf("expected the ban on the device to not apply to the attacker, got %+v", v)
+	}
+	if v, _ := r.CheckLogin("player", "xuid", "", "device-id"); v.Allowed || v.Key != DeviceKey(stolen) {
+		t.Fatalf("expected the device ban to deny login by its pseudonym, got %+v", v)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	r.SetIdentifierKeys([]byte("old"))
+	r.AddAlias("player", "1.2.3.4", "device-id", "xuid")
+	dev, _ := r.Device("device-id")
+	dev.Ban(NewPunishment("hacking", "staff"))
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	r = New(p, nil)
+	r.SetIdentifierKeys([]byte("new"), []byte("old"))
+	merged, err := r.Rekey(IpKey("1.2.3.4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != 1 {
+		t.Fatalf("expected one container to be merged, got %v", merged)
+	}
+	if _, ok := p.data[IpIdentifier][Pseudonym([]byte("old"), IpIdentifier, "1.2.3.4")]; ok {
+		t.Fatalf("expected container stored by the old pseudonym to be deleted")
+	}
+	dev, _ = r.Device("device-id")
+	if !dev.Banned() {
+		t.Fatalf("expected device ban to be migrated when loaded")
+	}
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.data[DeviceIdentifier][Pseudonym([]byte("new"), DeviceIdentifier, "device-id")]; !ok {
+		t.Fatalf("expected device to be stored by the new pseudonym")
+	}
+}
+
+func TestRekeyXboxes(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	r.SetIdentifierKeys([]byte("old"))
+	r.AddAlias("player", "1.2.3.4", "device-id", "xuid")
+	r.AddAlias("alt", "1.2.3.4", "other-device", "alt")
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	r = New(p, nil)
+	r.SetIdentifierKeys([]byte("new"), []byte("old"))
+	if _, err := r.Rekey(IpKey("1.2.3.4"), DeviceKey("device-id")); err != nil {
+		t.Fatal(err)
+	}
+	x, _ := r.Xbox("xuid")
+	ip, device := Pseudonym([]byte("new"), IpIdentifier, "1.2.3.4"), Pseudonym([]byte("new"), DeviceIdentifier, "device-id")
+	if ips, devices := x.Ips(), x.Devices(); len(ips) != 1 || ips[0] != ip || len(devices) != 1 || devices[0] != device {
+		t.Fatalf("expected xbox to record the new pseudonyms, got %v and %v", ips, devices)
+	}
+	g, err := r.Linked("xuid", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.Accounts["alt"]; !ok {
+		t.Fatalf("expected alt to still be linked after rekeying, got %v", g.Accounts)
+	}
+}
@@ -1,11 +1,36 @@
 package punishment
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotActive is returned when trying to lift or change a punishment of a Kind that a container has no active
+	// punishment of.
+	ErrNotActive = errors.New("no active punishment")
+	// ErrNotBanned is returned when trying to lift the ban of a container that isn't banned.
+	ErrNotBanned = errors.New("not banned")
+	// ErrNotMuted is returned when trying to lift the mute of a container that isn't muted.
+	ErrNotMuted = errors.New("not muted")
+)
+
+// Entry is a punishment in the record of a player, along with the Kind it was issued as.
+type Entry struct {
+	// Kind is the Kind the punishment was issued as.
+	Kind Kind
+	// Punishment is the punishment issued.
+	Punishment Punishment
+}
 
 // Punishment represents a generic punishment on a player.
 type Punishment struct {
-	// Time is the Time when this punishment was issued.
-	Time int `json:"time"`
+	// ID is the case ID of this punishment, which is unique and sorts by the time the punishment was issued.
+	ID string `json:"id,omitempty"`
+	// Time is the time at which this punishment was issued.
+	Time time.Time `json:"time"`
 	// Reason is the Reason for this specific punishment.
 	PunishmentReason string `json:"reason"`
 	// issuer is the name of the specific user that issued this punishment
@@ -13,37 +38,150 @@ type Punishment struct {
 	// expires holds a bool saying whether this punishment expires or not.
 	Expires bool `json:"expires"`
 	// ExpirationTime represents when this punishment expires, it's irrelevant unless Expires is true.
-	ExpirationTime int `json:"duration"`
+	ExpirationTime time.Time `json:"expiration_time"`
+	// LiftedBy is the name of the user that lifted this punishment before it ended, empty if it was never lifted.
+	LiftedBy string `json:"lifted_by,omitempty"`
+	// LiftedAt is the time at which this punishment was lifted, it's irrelevant unless Lifted returns true.
+	LiftedAt time.Time `json:"lifted_at"`
+	// LiftReason is the reason given for lifting this punishment.
+	LiftReason string `json:"lift_reason,omitempty"`
+	// Category is the offence category this punishment was issued for, empty if it wasn't issued for one.
+	Category string `json:"category,omitempty"`
+	// Preset is the ID of the Preset this punishment was issued with, empty if it was issued with a free text reason
+	// only.
+	Preset string `json:"preset,omitempty"`
+	// Report is the ID of the report this punishment was escalated from, empty if it wasn't issued for a report.
+	Report string `json:"report,omitempty"`
+	// PunishmentNotes holds the notes staff left on this punishment, in the order they were left.
+	PunishmentNotes []Note `json:"notes,omitempty"`
+	// Evidence holds references to the evidence this punishment was issued on.
+	Evidence []Evidence `json:"evidence,omitempty"`
 }
 
-// NewPunishment returns a new PunishmentReason object.
-func NewPunishment(time int, reason string, issuer string) Punishment {
+// NewPunishment returns a new permanent Punishment issued at the current time. It has no case ID yet: every container
+// it is issued on assigns it a case ID of its own, so that the same punishment can be issued on several containers.
+func NewPunishment(reason string, issuer string) Punishment {
 	return Punishment{
-		Time:             time,
+		Time:             time.Now(),
 		PunishmentReason: reason,
 		PunishmentIssuer: issuer,
 	}
 }
 
+// NewTemporary returns a new Punishment issued at the current time that expires after the duration passed.
+func NewTemporary(duration time.Duration, reason string, issuer string) Punishment {
+	p := NewPunishment(reason, issuer)
+	p.Expires = true
+	p.ExpirationTime = p.Time.Add(duration)
+	return p
+}
+
 // Issuer returns the user who did this specific ban.
-func (p *Punishment) Issuer() string {
+func (p Punishment) Issuer() string {
 	return p.PunishmentIssuer
 }
 
 // Reason returns the reason for the ban.
-func (p *Punishment) Reason() string {
+func (p Punishment) Reason() string {
 	return p.PunishmentReason
 }
 
-// Notes returns the notes for the ban.
-func (p *Punishment) Notes() string {
-	return p.Notes()
+// Notes returns the notes staff left on the punishment.
+func (p Punishment) Notes() []Note {
+	return p.PunishmentNotes
+}
+
+// Empty returns whether the punishment is the default value, which containers use when there is no punishment.
+func (p Punishment) Empty() bool {
+	return p.Time.IsZero() && p.PunishmentReason == "" && p.PunishmentIssuer == ""
+}
+
+// Active returns whether the punishment is currently in effect, meaning it isn't empty and hasn't expired yet.
+func (p Punishment) Active() bool {
+	return p.ActiveAt(time.Now())
+}
+
+// ActiveAt returns whether the punishment is in effect at the time passed.
+func (p Punishment) ActiveAt(t time.Time) bool {
+	return !p.Empty() && !p.Lifted() && !p.ExpiredAt(t)
+}
+
+// Lifted returns whether the punishment was lifted by a user before it ended.
+func (p Punishment) Lifted() bool {
+	return !p.LiftedAt.IsZero()
+}
+
+// lift returns a copy of the punishment marked as lifted by the user passed at the time passed.
+func (p Punishment) lift(by, reason string, t time.Time) Punishment {
+	p.LiftedBy = by
+	p.LiftedAt = t
+	p.LiftReason = reason
+	return p
 }
 
 // Expired tells weather a specific ban has expired or not
-func (p *Punishment) Expired() bool {
+func (p Punishment) Expired() bool {
+	return p.ExpiredAt(time.Now())
+}
+
+// ExpiredAt returns whether the punishment has expired at the time passed. Punishments that don't expire never
+// return true.
+func (p Punishment) ExpiredAt(t time.Time) bool {
 	if !p.Expires {
 		return false
 	}
-	return time.Now().Second() > p.ExpirationTime
+	return !t.Before(p.ExpirationTime)
+}
+
+// Remaining returns how much time is left until the punishment expires. It returns 0 if the punishment has already
+// expired, or if it doesn't expire at all, in which case Expires is false.
+func (p Punishment) Remaining() time.Duration {
+	if !p.Expires {
+		return 0
+	}
+	if d := time.Until(p.ExpirationTime); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// UnmarshalJSON ...
+func (p *Punishment) UnmarshalJSON(b []byte) error {
+	type punishment Punishment
+	aux := struct {
+		*punishment
+		// Time shadows the Time of the punishment, so that both timestamps and the old unix time in seconds can be
+		// decoded.
+		Time json.RawMessage `json:"time"`
+		// Duration is the old representation of ExpirationTime, holding a unix time in seconds.
+		Duration *int64 `json:"duration"`
+	}{punishment: (*punishment)(p)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if len(aux.Time) != 0 && string(aux.Time) != "null" {
+		if aux.Time[0] == '"' {
+			if err := json.Unmarshal(aux.Time, &p.Time); err != nil {
+				return err
+			}
+		} else {
+			var unix int64
+			if err := json.Unmarshal(aux.Time, &unix); err != nil {
+				return fmt.Errorf("invalid punishment time %s: %w", aux.Time, err)
+			}
+			if unix != 0 {
+				// Old data stored a time of 0 for containers without a punishment, which must stay empty.
+				p.Time = time.Unix(unix, 0)
+			}
+		}
+	}
+	if aux.Duration != nil && *aux.Duration != 0 && p.ExpirationTime.IsZero() {
+		p.ExpirationTime = time.Unix(*aux.Duration, 0)
+		if p.ExpirationTime.Before(p.Time) {
+			// Some data stored the duration of the punishment rather than the time it expires at, which we can tell
+			// by it expiring before it was issued.
+			p.ExpirationTime = p.Time.Add(time.Duration(*aux.Duration) * time.Second)
+		}
+	}
+	return nil
 }
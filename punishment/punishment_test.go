@@ -0,0 +1,67 @@
+package punishment
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalLegacyPunishment(t *testing.T) {
+	var p Punishment
+	if err := json.Unmarshal([]byte(`{"time":1600000000,"reason":"hacking","banner":"staff","expires":true,"duration":1600086400}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Time.Equal(time.Unix(1600000000, 0)) {
+		t.Fatalf("unexpected issue time %v", p.Time)
+	}
+	if !p.ExpirationTime.Equal(time.Unix(1600086400, 0)) {
+		t.Fatalf("unexpected expiration time %v", p.ExpirationTime)
+	}
+	if p.Reason() != "hacking" || p.Issuer() != "staff" {
+		t.Fatalf("unexpected reason or issuer: %v, %v", p.Reason(), p.Issuer())
+	}
+	if !p.Expired() {
+		t.Fatalf("expected legacy punishment to have expired")
+	}
+}
+
+func TestUnmarshalLegacyEmptyPunishment(t *testing.T) {
+	const empty = `{"time":0,"reason":"","banner":"","expires":false,"duration":0}`
+	var p Punishment
+	if err := json.Unmarshal([]byte(empty), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Empty() || !p.ExpirationTime.IsZero() {
+		t.Fatalf("expected legacy empty punishment to be empty, got %+v", p)
+	}
+
+	var d XboxData
+	if err := json.Unmarshal([]byte(`{"current_ban":`+empty+`,"past_bans":[],"current_mute":`+empty+`,"past_mutes":[]}`), &d); err != nil {
+		t.Fatal(err)
+	}
+	x := d.Container().(*Xbox)
+	if x.Banned() || x.Muted() {
+		t.Fatalf("expected legacy container without punishments not to be banned or muted")
+	}
+	if len(x.Record()) != 0 {
+		t.Fatalf("expected no punishments to be recorded, got %v", x.Record())
+	}
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	p := NewTemporary(time.Hour, "spam", "staff")
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Punishment
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Time.Equal(p.Time) || !decoded.ExpirationTime.Equal(p.ExpirationTime) {
+		t.Fatalf("timestamps were not kept: %v, %v", decoded.Time, decoded.ExpirationTime)
+	}
+	if !decoded.Active() {
+		t.Fatalf("expected decoded punishment to be active")
+	}
+}
@@ -0,0 +1,545 @@
+package punishment
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Punishable is a Container that punishments of any Kind can be issued on. Xbox, Ip and Device implement it by
+// embedding Punishments, and containers of custom types may do the same.
+type Punishable interface {
+	Container
+	// Punish issues a punishment of a Kind.
+	Punish(k Kind, p Punishment) error
+	// Punished returns whether there is an active punishment of a Kind.
+	Punished(k Kind) bool
+	// Current returns the active punishment of a Kind that lasts longest.
+	Current(k Kind) Punishment
+	// Active returns all active punishments of a Kind.
+	Active(k Kind) []Punishment
+	// History returns all past punishments of a Kind.
+	History(k Kind) []Punishment
+	// Lift lifts all active punishments of a Kind.
+	Lift(k Kind, by, reason string) error
+	// Extend extends the current punishment of a Kind.
+	Extend(k Kind, d time.Duration) (Punishment, error)
+	// Expire moves all punishments that expired into the history.
+	Expire(t time.Time) []Entry
+	// Record returns all punishments of every Kind.
+	Record() []Entry
+	// Case returns the punishment with a case ID.
+	Case(id string) (Entry, bool)
+	// LiftCase lifts the punishment with a case ID.
+	LiftCase(id, by, reason string) error
+	// Shorten shortens the punishment with a case ID.
+	Shorten(id string, expiration time.Time) (Punishment, error)
+	// Update updates the punishment with a case ID.
+	Update(id string, f func(p *Punishment) error) error
+}
+
+// Punishments holds the punishments of every Kind issued on a container. Containers that can be punished embed it,
+// so that they all share a single implementation of issuing, lifting and expiring punishments.
+type Punishments struct {
+	// active holds the punishments of every Kind that are in effect. Punishments that expired stay in it until they
+	// are moved into the history by Expire.
+	active map[Kind][]Punishment
+	// past holds the history of punishments of every Kind.
+	past map[Kind][]Punishment
+
+	lock sync.RWMutex
+}
+
+// PunishmentsData is the data representation of Punishments, shared by the data of every container.
+type PunishmentsData struct {
+	// Active represents active within Punishments.
+	Active map[Kind][]Punishment `json:"active,omitempty"`
+	// Past represents past within Punishments.
+	Past map[Kind][]Punishment `json:"past,omitempty"`
+}
+
+// Punish issues a punishment of the Kind passed. Punishments of instant Kinds go straight into the history, while
+// for Kinds that don't stack the current punishment is moved into the history. Punishments without a case ID are
+// given one. An error is returned if the Kind isn't registered, if the punishment expires while the Kind can't, or if
+// a punishment with the same case ID was issued already.
+func (p *Punishments) Punish(k Kind, punishment Punishment) error {
+	props, ok := k.Properties()
+	if !ok {
+		return fmt.Errorf("unknown punishment kind %q", k)
+	}
+	if punishment.Expires && !props.Expires {
+		return fmt.Errorf("punishments of kind %q can't expire", k)
+	}
+	if punishment.ID == "" {
+		punishment.ID = newCaseID()
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.holds(punishment.ID) {
+		return fmt.Errorf("punishment with case id %v already issued", punishment.ID)
+	}
+	if p.active == nil {
+		p.active, p.past = map[Kind][]Punishment{}, map[Kind][]Punishment{}
+	}
+	switch {
+	case props.Instant:
+		p.past[k] = append(p.past[k], punishment)
+	case props.Stacks:
+		p.active[k] = append(p.active[k], punishment)
+	default:
+		p.past[k] = append(p.past[k], p.active[k]...)
+		p.active[k] = []Punishment{punishment}
+	}
+	return nil
+}
+
+// holds returns whether a punishment with the case ID passed was issued. Callers of this method should have the
+// mutex within Punishments locked.
+func (p *Punishments) holds(id string) bool {
+	for _, m := range []map[Kind][]Punishment{p.active, p.past} {
+		for _, punishments := range m {
+			for _, punishment := range punishments {
+				if punishment.ID == id {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Punished returns whether there is an active punishment of the Kind passed.
+func (p *Punishments) Punished(k Kind) bool {
+	return len(p.Active(k)) > 0
+}
+
+// Current returns the active punishment of the Kind passed that lasts longest, or an empty punishment if there is
+// none.
+func (p *Punishments) Current(k Kind) Punishment {
+	var current Punishment
+	for _, a := range p.Active(k) {
+		if current.Empty() || outlasts(a, current) {
+			current = a
+		}
+	}
+	return current
+}
+
+// Active returns all punishments of the Kind passed that are in effect.
+func (p *Punishments) Active(k Kind) []Punishment {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	now := time.Now()
+	var active []Punishment
+	for _, a := range p.active[k] {
+		if a.ActiveAt(now) {
+			active = append(active, a)
+		}
+	}
+	return active
+}
+
+// History returns all past punishments of the Kind passed, in the order they were moved into the history.
+func (p *Punishments) History(k Kind) []Punishment {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.past[k]
+}
+
+// Lift lifts every active punishment of the Kind passed, recording who lifted them, when and why before moving them
+// into the history. ErrNotActive is returned if there is no active punishment of the Kind.
+func (p *Punishments) Lift(k Kind, by, reason string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	var remaining []Punishment
+	lifted := false
+	for _, a := range p.active[k] {
+		if !a.ActiveAt(now) {
+			remaining = append(remaining, a)
+			continue
+		}
+		p.past[k] = append(p.past[k], a.lift(by, reason, now))
+		lifted = true
+	}
+	if !lifted {
+		return ErrNotActive
+	}
+	p.active[k] = remaining
+	return nil
+}
+
+// Extend extends the current punishment of the Kind passed by the duration passed and returns the extended
+// punishment. Punishments that don't expire are left as they are. ErrNotActive is returned if there is no active
+// punishment of the Kind.
+func (p *Punishments) Extend(k Kind, d time.Duration) (Punishment, error) {
+	current := p.Current(k)
+	if current.Empty() {
+		return Punishment{}, ErrNotActive
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for i, a := range p.active[k] {
+		if a.ID == current.ID && a.Expires {
+			p.active[k][i].ExpirationTime = a.ExpirationTime.Add(d)
+			return p.active[k][i], nil
+		}
+	}
+	return current, nil
+}
+
+// Expire moves every punishment that has expired at the time passed into the history, and returns them.
+func (p *Punishments) Expire(t time.Time) []Entry {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var expired []Entry
+	for k, active := range p.active {
+		var remaining []Punishment
+		for _, a := range active {
+			if a.ExpiredAt(t) {
+				p.past[k] = append(p.past[k], a)
+				expired = append(expired, Entry{Kind: k, Punishment: a})
+				continue
+			}
+			remaining = append(remaining, a)
+		}
+		p.active[k] = remaining
+	}
+	return expired
+}
+
+// Record returns every punishment, active or past, of every Kind, ordered by the time they were issued.
+func (p *Punishments) Record() []Entry {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	var entries []Entry
+	for _, m := range []map[Kind][]Punishment{p.past, p.active} {
+		for k, punishments := range m {
+			for _, punishment := range punishments {
+				entries = append(entries, Entry{Kind: k, Punishment: punishment})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Punishment.Time.Before(entries[j].Punishment.Time)
+	})
+	return entries
+}
+
+// Case returns the punishment with the case ID passed, along with the Kind it was issued as, and false if there is no
+// such punishment.
+func (p *Punishments) Case(id string) (Entry, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, m := range []map[Kind][]Punishment{p.active, p.past} {
+		for k, punishments := range m {
+			for _, punishment := range punishments {
+				if punishment.ID == id {
+					return Entry{Kind: k, Punishment: punishment}, true
+				}
+			}
+		}
+	}
+	return Entry{}, false
+}
+
+// LiftCase lifts the punishment with the case ID passed, recording who lifted it, when and why before moving it into
+// the history. ErrCaseNotFound is returned if there is no such punishment, and ErrNotActive if it isn't active.
+func (p *Punishments) LiftCase(id, by, reason string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	for k, active := range p.active {
+		for i, a := range active {
+			if a.ID != id {
+				continue
+			}
+			if !a.ActiveAt(now) {
+				return ErrNotActive
+			}
+			p.past[k] = append(p.past[k], a.lift(by, reason, now))
+			p.active[k] = append(active[:i:i], active[i+1:]...)
+			return nil
+		}
+	}
+	for _, past := range p.past {
+		for _, a := range past {
+			if a.ID == id {
+				return ErrNotActive
+			}
+		}
+	}
+	return ErrCaseNotFound
+}
+
+// Shorten shortens the active punishment with the case ID passed, so that it expires at the time passed, and returns
+// the shortened punishment. Permanent punishments become temporary. ErrCaseNotFound is returned if there is no active
+// punishment with the case ID, and an error is returned if the time isn't before the punishment would otherwise expire
+// or if its Kind can't expire.
+func (p *Punishments) Shorten(id string, expiration time.Time) (Punishment, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for k, active := range p.active {
+		for i, a := range active {
+			if a.ID != id {
+				continue
+			}
+			if props, _ := k.Properties(); !props.Expires {
+				return Punishment{}, fmt.Errorf("punishments of kind %q can't expire", k)
+			}
+			if a.Expires && !expiration.Before(a.ExpirationTime) {
+				return Punishment{}, fmt.Errorf("punishment already expires at %v", a.ExpirationTime)
+			}
+			active[i].Expires, active[i].ExpirationTime = true, expiration
+			return active[i], nil
+		}
+	}
+	return Punishment{}, ErrCaseNotFound
+}
+
+// Update calls f with the punishment with the case ID passed, active or past, so that it can be changed. Changes to
+// the ID of the punishment are ignored, and nothing is changed if f returns an error. ErrCaseNotFound is returned if
+// there is no punishment with the case ID.
+func (p *Punishments) Update(id string, f func(p *Punishment) error) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, m := range []map[Kind][]Punishment{p.active, p.past} {
+		for _, punishments := range m {
+			for i, punishment := range punishments {
+				if punishment.ID != id {
+					continue
+				}
+				if err := f(&punishment); err != nil {
+					return err
+				}
+				punishment.ID = id
+				punishments[i] = punishment
+				return nil
+			}
+		}
+	}
+	return ErrCaseNotFound
+}
+
+// Ban issues a ban, moving the current ban into the history. An error is returned if the ban can't be issued, see
+// Punish.
+func (p *Punishments) Ban(b Punishment) error {
+	return p.Punish(KindBan, b)
+}
+
+// Banned returns whether there is an active ban.
+func (p *Punishments) Banned() bool {
+	return p.Punished(KindBan)
+}
+
+// CurrentBan returns the active ban, or an empty punishment if there is none.
+func (p *Punishments) CurrentBan() Punishment {
+	return p.Current(KindBan)
+}
+
+// BanHistory returns all past bans.
+func (p *Punishments) BanHistory() []Punishment {
+	return p.History(KindBan)
+}
+
+// Unban lifts the active ban. ErrNotBanned is returned if there is none.
+func (p *Punishments) Unban(by, reason string) error {
+	if err := p.Lift(KindBan, by, reason); errors.Is(err, ErrNotActive) {
+		return ErrNotBanned
+	}
+	return nil
+}
+
+// ExtendBan extends the active ban by the duration passed. ErrNotBanned is returned if there is none.
+func (p *Punishments) ExtendBan(d time.Duration) (Punishment, error) {
+	b, err := p.Extend(KindBan, d)
+	if errors.Is(err, ErrNotActive) {
+		return b, ErrNotBanned
+	}
+	return b, err
+}
+
+// Mute issues a mute, moving the current mute into the history. An error is returned if the mute can't be issued,
+// see Punish.
+func (p *Punishments) Mute(m Punishment) error {
+	return p.Punish(KindMute, m)
+}
+
+// Muted returns whether there is an active mute.
+func (p *Punishments) Muted() bool {
+	return p.Punished(KindMute)
+}
+
+// CurrentMute returns the active mute, or an empty punishment if there is none.
+func (p *Punishments) CurrentMute() Punishment {
+	return p.Current(KindMute)
+}
+
+// MuteHistory returns all past mutes.
+func (p *Punishments) MuteHistory() []Punishment {
+	return p.History(KindMute)
+}
+
+// Unmute lifts the active mute. ErrNotMuted is returned if there is none.
+func (p *Punishments) Unmute(by, reason string) error {
+	if err := p.Lift(KindMute, by, reason); errors.Is(err, ErrNotActive) {
+		return ErrNotMuted
+	}
+	return nil
+}
+
+// Warn records a warning. An error is returned if the warning expires, as warnings can't.
+func (p *Punishments) Warn(w Punishment) error {
+	return p.Punish(KindWarning, w)
+}
+
+// Warnings returns all warnings recorded.
+func (p *Punishments) Warnings() []Punishment {
+	return p.History(KindWarning)
+}
+
+// Kick records a kick. It doesn't disconnect anyone, which is left to the server. An error is returned if the kick
+// expires, as kicks can't.
+func (p *Punishments) Kick(k Punishment) error {
+	return p.Punish(KindKick, k)
+}
+
+// Kicks returns all kicks recorded.
+func (p *Punishments) Kicks() []Punishment {
+	return p.History(KindKick)
+}
+
+// data returns the data representation of the Punishments.
+func (p *Punishments) data() PunishmentsData {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	// The punishments are copied, so that changes made to them afterwards don't affect the data handed out.
+	d := PunishmentsData{Active: map[Kind][]Punishment{}, Past: map[Kind][]Punishment{}}
+	for k, active := range p.active {
+		if len(active) > 0 {
+			d.Active[k] = slices.Clone(active)
+		}
+	}
+	for k, past := range p.past {
+		if len(past) > 0 {
+			d.Past[k] = slices.Clone(past)
+		}
+	}
+	return d
+}
+
+// empty returns whether no punishments have been issued at all.
+func (p *Punishments) empty() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, m := range []map[Kind][]Punishment{p.active, p.past} {
+		for _, punishments := range m {
+			if len(punishments) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// merge merges the punishments of o into p. For Kinds that don't stack, the active punishment that lasts longest is
+// kept and the others are moved into the history, which is sorted by the time punishments were issued.
+func (p *Punishments) merge(o *Punishments) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.active == nil {
+		p.active, p.past = map[Kind][]Punishment{}, map[Kind][]Punishment{}
+	}
+	for k, active := range o.active {
+		combined := append(p.active[k], active...)
+		if props, _ := k.Properties(); props.Stacks || len(combined) < 2 {
+			p.active[k] = combined
+			continue
+		}
+		longest := 0
+		for i, a := range combined {
+			if outlasts(a, combined[longest]) {
+				longest = i
+			}
+		}
+		for i, a := range combined {
+			if i != longest {
+				p.past[k] = append(p.past[k], a)
+			}
+		}
+		p.active[k] = []Punishment{combined[longest]}
+	}
+	for k, past := range o.past {
+		p.past[k] = append(p.past[k], past...)
+	}
+	for _, past := range p.past {
+		sort.SliceStable(past, func(i, j int) bool {
+			return past[i].Time.Before(past[j].Time)
+		})
+	}
+}
+
+// legacyData holds the fields the data of containers was stored with before punishments of every Kind were stored
+// together in PunishmentsData. It is only read, so that old data can still be loaded.
+type legacyData struct {
+	CurrentBan  *Punishment  `json:"current_ban,omitempty"`
+	PastBans    []Punishment `json:"past_bans,omitempty"`
+	CurrentMute *Punishment  `json:"current_mute,omitempty"`
+	PastMutes   []Punishment `json:"past_mutes,omitempty"`
+	Warnings    []Punishment `json:"warnings,omitempty"`
+	Kicks       []Punishment `json:"kicks,omitempty"`
+}
+
+// load loads the punishments held by the data passed into p, including those held by the legacy fields of l.
+func (p *Punishments) load(d PunishmentsData, l legacyData) {
+	p.active, p.past = map[Kind][]Punishment{}, map[Kind][]Punishment{}
+	for k, active := range d.Active {
+		p.active[k] = active
+	}
+	for k, past := range d.Past {
+		p.past[k] = past
+	}
+	if l.CurrentBan != nil && !l.CurrentBan.Empty() {
+		p.active[KindBan] = append(p.active[KindBan], *l.CurrentBan)
+	}
+	if l.CurrentMute != nil && !l.CurrentMute.Empty() {
+		p.active[KindMute] = append(p.active[KindMute], *l.CurrentMute)
+	}
+	for k, legacy := range map[Kind][]Punishment{KindBan: l.PastBans, KindMute: l.PastMutes, KindWarning: l.Warnings, KindKick: l.Kicks} {
+		if len(legacy) > 0 {
+			p.past[k] = append(legacy, p.past[k]...)
+		}
+	}
+	// Punishments stored before case IDs were introduced are given one derived from the punishment. Empty punishments,
+	// which were once stored in the history, are dropped.
+	seen := map[string]struct{}{}
+	for _, m := range []map[Kind][]Punishment{p.active, p.past} {
+		for k, punishments := range m {
+			kept := punishments[:0:0]
+			for _, punishment := range punishments {
+				if punishment.Empty() {
+					continue
+				}
+				for n := 0; punishment.ID == ""; n++ {
+					if id := legacyCaseID(k, punishment, n); !contains(seen, id) {
+						punishment.ID = id
+					}
+				}
+				seen[punishment.ID] = struct{}{}
+				kept = append(kept, punishment)
+			}
+			m[k] = kept
+		}
+	}
+}
+
+// contains returns whether the set passed contains the ID passed.
+func contains(set map[string]struct{}, id string) bool {
+	_, ok := set[id]
+	return ok
+}
@@ -0,0 +1,318 @@
+package punishment
+
+import (
+	"fmt"
+	"golang.org/x/exp/slices"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RangeBan is a ban on every ip address within a range of addresses.
+type RangeBan struct {
+	// Prefix is the CIDR block of addresses banned.
+	Prefix netip.Prefix `json:"prefix"`
+	// Ban is the ban applied to the addresses within Prefix.
+	Ban Punishment `json:"ban"`
+}
+
+// Ranges holds all ip range bans. Unlike other containers, there is only a single Ranges container, stored by a Key
+// of RangeIdentifier.
+type Ranges struct {
+	// bans holds the current ban of every range banned, ordered from most to least specific.
+	bans []RangeBan
+	// pastBans holds all range bans that were replaced, lifted or expired.
+	pastBans []RangeBan
+
+	lock sync.RWMutex
+}
+
+// RangesData is a data representation of Ranges used for loading and saving range bans.
+type RangesData struct {
+	// Bans represents bans within Ranges.
+	Bans []RangeBan `json:"bans"`
+	// PastBans represents pastBans within Ranges.
+	PastBans []RangeBan `json:"past_bans"`
+}
+
+func (d *RangesData) Container() Container {
+	r := &Ranges{bans: d.Bans, pastBans: d.PastBans}
+	// Range bans stored before case IDs were introduced are given one derived from the ban.
+	seen := map[string]struct{}{}
+	for _, bans := range [][]RangeBan{r.bans, r.pastBans} {
+		for i := range bans {
+			for n := 0; bans[i].Ban.ID == ""; n++ {
+				if id := legacyCaseID(KindBan, bans[i].Ban, n); !contains(seen, id) {
+					bans[i].Ban.ID = id
+				}
+			}
+			seen[bans[i].Ban.ID] = struct{}{}
+		}
+	}
+	r.sort()
+	return r
+}
+
+// ParsePrefix parses a CIDR block such as "10.0.0.0/8" or "2001:db8::/32". A single address is parsed as a block
+// holding only that address. The prefix returned is masked, so that "10.1.2.3/8" is the same as "10.0.0.0/8".
+func ParsePrefix(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		addr, addrErr := netip.ParseAddr(cidr)
+		if addrErr != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid ip range %q: %w", cidr, err)
+		}
+		addr = addr.Unmap()
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// Ban bans every ip address within the prefix passed. If the prefix was already banned, the previous ban is moved
+// into the history. Bans without a case ID are given one.
+func (r *Ranges) Ban(prefix netip.Prefix, b Punishment) {
+	if b.ID == "" {
+		b.ID = newCaseID()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	prefix = prefix.Masked()
+	for i, rb := range r.bans {
+		if rb.Prefix == prefix {
+			r.pastBans = append(r.pastBans, rb)
+			r.bans[i].Ban = b
+			return
+		}
+	}
+	r.bans = append(r.bans, RangeBan{Prefix: prefix, Ban: b})
+	r.sort()
+}
+
+// Unban lifts the ban of the prefix passed, recording who lifted it, when and why before moving it into the history.
+// ErrNotBanned is returned if the prefix has no active ban. Bans on other prefixes covering the prefix are not
+// lifted.
+func (r *Ranges) Unban(prefix netip.Prefix, by, reason string) error {
+	_, err := r.unban(prefix, by, reason)
+	return err
+}
+
+// unban lifts the ban of the prefix passed like Unban, returning the lifted ban.
+func (r *Ranges) unban(prefix netip.Prefix, by, reason string) (Punishment, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	prefix = prefix.Masked()
+	now := time.Now()
+	for i, rb := range r.bans {
+		if rb.Prefix != prefix || !rb.Ban.ActiveAt(now) {
+			continue
+		}
+		rb.Ban = rb.Ban.lift(by, reason, now)
+		r.pastBans = append(r.pastBans, rb)
+		r.bans = append(r.bans[:i:i], r.bans[i+1:]...)
+		return rb.Ban, nil
+	}
+	return Punishment{}, ErrNotBanned
+}
+
+// Expire moves every range ban that has expired at the time passed into the history, and returns them.
+func (r *Ranges) Expire(t time.Time) []RangeBan {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var expired []RangeBan
+	remaining := r.bans[:0:0]
+	for _, rb := range r.bans {
+		if rb.Ban.ExpiredAt(t) {
+			r.pastBans = append(r.pastBans, rb)
+			expired = append(expired, rb)
+			continue
+		}
+		remaining = append(remaining, rb)
+	}
+	r.bans = remaining
+	return expired
+}
+
+// Bans returns the current ban of every range, ordered from most to least specific. Bans that have expired are
+// included.
+func (r *Ranges) Bans() []RangeBan {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]RangeBan(nil), r.bans...)
+}
+
+// BanHistory returns all range bans that were replaced or lifted.
+func (r *Ranges) BanHistory() []RangeBan {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.pastBans
+}
+
+// Covering returns every active range ban covering the address passed, ordered from most to least specific.
+func (r *Ranges) Covering(addr netip.Addr) []RangeBan {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	addr = addr.Unmap()
+	now := time.Now()
+	var covering []RangeBan
+	for _, rb := range r.bans {
+		if rb.Prefix.Contains(addr) && rb.Ban.ActiveAt(now) {
+			covering = append(covering, rb)
+		}
+	}
+	return covering
+}
+
+// Match returns the most specific active range ban covering the address passed, if any.
+func (r *Ranges) Match(addr netip.Addr) (RangeBan, bool) {
+	covering := r.Covering(addr)
+	if len(covering) == 0 {
+		return RangeBan{}, false
+	}
+	return covering[0], true
+}
+
+// Record returns every range ban, current or past, as punishments of KindBan ordered by the time they were issued.
+func (r *Ranges) Record() []Entry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	entries := make([]Entry, 0, len(r.bans)+len(r.pastBans))
+	for _, bans := range [][]RangeBan{r.pastBans, r.bans} {
+		for _, rb := range bans {
+			entries = append(entries, Entry{Kind: KindBan, Punishment: rb.Ban})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Punishment.Time.Before(entries[j].Punishment.Time)
+	})
+	return entries
+}
+
+// Case returns the range ban with the case ID passed as a punishment of KindBan, and false if there is no such ban.
+func (r *Ranges) Case(id string) (Entry, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, bans := range [][]RangeBan{r.bans, r.pastBans} {
+		for _, rb := range bans {
+			if rb.Ban.ID == id {
+				return Entry{Kind: KindBan, Punishment: rb.Ban}, true
+			}
+		}
+	}
+	return Entry{}, false
+}
+
+// prefix returns the prefix of the range ban with the case ID passed, and false if there is no such ban.
+func (r *Ranges) prefix(id string) (netip.Prefix, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, bans := range [][]RangeBan{r.bans, r.pastBans} {
+		for _, rb := range bans {
+			if rb.Ban.ID == id {
+				return rb.Prefix, true
+			}
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// LiftCase lifts the range ban with the case ID passed, recording who lifted it, when and why before moving it into
+// the history. ErrCaseNotFound is returned if there is no such ban, and ErrNotActive if it isn't active.
+func (r *Ranges) LiftCase(id, by, reason string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	for i, rb := range r.bans {
+		if rb.Ban.ID != id {
+			continue
+		}
+		if !rb.Ban.ActiveAt(now) {
+			return ErrNotActive
+		}
+		rb.Ban = rb.Ban.lift(by, reason, now)
+		r.pastBans = append(r.pastBans, rb)
+		r.bans = append(r.bans[:i:i], r.bans[i+1:]...)
+		return nil
+	}
+	for _, rb := range r.pastBans {
+		if rb.Ban.ID == id {
+			return ErrNotActive
+		}
+	}
+	return ErrCaseNotFound
+}
+
+// Data returns the data representation of Ranges.
+func (r *Ranges) Data() DataHolder {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return &RangesData{
+		Bans:     slices.Clone(r.bans),
+		PastBans: slices.Clone(r.pastBans),
+	}
+}
+
+// sort orders the bans from most to least specific. Callers of this method should have the mutex within Ranges
+// locked.
+func (r *Ranges) sort() {
+	sort.SliceStable(r.bans, func(i, j int) bool {
+		return r.bans[i].Prefix.Bits() > r.bans[j].Prefix.Bits()
+	})
+}
+
+// Ranges attempts to load the ip range bans and return them.
+func (r *Registry) Ranges() (*Ranges, error) {
+	return Get[*Ranges](r, rangesKey)
+}
+
+// BanRange bans every ip address within the CIDR block passed, such as "10.0.0.0/8" or "2001:db8::/32". The ban is
+// always given a new case ID, and the ban issued is returned.
+func (r *Registry) BanRange(cidr string, b Punishment) (Punishment, error) {
+	prefix, err := ParsePrefix(cidr)
+	if err != nil {
+		return Punishment{}, err
+	}
+	ranges, err := r.Ranges()
+	if err != nil {
+		return Punishment{}, err
+	}
+	b.ID = newCaseID()
+	ranges.Ban(prefix, b)
+	r.emit(Event{Type: EventIssued, Key: rangesKey, Range: prefix.Masked(), Kind: KindBan, Punishment: b})
+	return b, nil
+}
+
+// UnbanRange lifts the ban of the CIDR block passed. by and reason are recorded on the lifted ban. ErrNotBanned is
+// returned if the block has no active ban.
+func (r *Registry) UnbanRange(cidr, by, reason string) error {
+	prefix, err := ParsePrefix(cidr)
+	if err != nil {
+		return err
+	}
+	ranges, err := r.Ranges()
+	if err != nil {
+		return err
+	}
+	b, err := ranges.unban(prefix, by, reason)
+	if err != nil {
+		return err
+	}
+	r.emit(Event{Type: EventLifted, Key: rangesKey, Range: prefix.Masked(), Kind: KindBan, Punishment: b})
+	return nil
+}
+
+// RangesCovering returns every active range ban covering the ip address passed, ordered from most to least specific.
+func (r *Registry) RangesCovering(ip string) ([]RangeBan, error) {
+	addr, err := parseAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := r.Ranges()
+	if err != nil {
+		return nil, err
+	}
+	return ranges.Covering(addr), nil
+}
@@ -0,0 +1,55 @@
+package punishment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRangeBans(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	wide, narrow := NewPunishment("abusive isp", "staff"), NewPunishment("botnet", "staff")
+	if _, err := r.BanRange("10.0.0.0/8", wide); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.BanRange("10.1.2.3/24", narrow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.BanRange("2001:db8::/32", wide); err != nil {
+		t.Fatal(err)
+	}
+
+	covering, err := r.RangesCovering("10.1.2.50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(covering) != 2 || covering[0].Prefix.String() != "10.1.2.0/24" || covering[1].Prefix.String() != "10.0.0.0/8" {
+		t.Fatalf("expected both ranges, most specific first, got %v", covering)
+	}
+
+	v, err := r.CheckLogin("player", "xuid", "10.1.2.50", "device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Allowed || v.Key != rangesKey || v.Range.String() != "10.1.2.0/24" {
+		t.Fatalf("expected the most specific range to deny login, got %+v", v)
+	}
+	if c, err := r.Load(v.Key); err != nil || len(c.(*Ranges).Bans()) != 3 {
+		t.Fatalf("expected the key of the verdict to load the range bans, got %v", err)
+	}
+	if v, _ = r.CheckLogin("player", "xuid", "2001:db8::1", "device"); v.Allowed {
+		t.Fatalf("expected ipv6 range to deny login")
+	}
+	if v, _ = r.CheckLogin("player", "xuid", "192.168.0.1", "device"); !v.Allowed {
+		t.Fatalf("expected address outside ranges to be allowed, got %+v", v)
+	}
+
+	if err := r.UnbanRange("10.1.2.0/24", "admin", "mistake"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UnbanRange("10.1.2.0/24", "admin", "mistake"); !errors.Is(err, ErrNotBanned) {
+		t.Fatalf("expected ErrNotBanned, got %v", err)
+	}
+	if covering, _ = r.RangesCovering("10.1.2.50"); len(covering) != 1 {
+		t.Fatalf("expected only the wide range to remain, got %v", covering)
+	}
+}
@@ -1,13 +1,16 @@
 package punishment
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 const IpIdentifier = "ip"
 const XuidIdentifier = "xuid"
 const DeviceIdentifier = "device"
+const RangeIdentifier = "ip_range"
 
 // Container is any data type that can hold user specific data
 type Container interface {
@@ -24,103 +27,284 @@ type AliasHandler func(username, ip, device, xuid string, data ...any) bool
 type Registry struct {
 	provider Provider
 
-	// punishments is a registry of all active punishments on a server. It is first index by puishment type which
-	// is represents as map[string]*Container. The identifiers for that map are the specific punishment identifier for
-	// the user.
-	punishments map[string]map[any]Container
+	// punishments is a registry of all active punishments on a server, indexed by the Key the container is stored by.
+	punishments map[Key]Container
 	// aliasHandler is called when a new alias is added with AddAlias.
 	aliasHandler AliasHandler
-	lock         sync.RWMutex
+	// evasionPolicy decides how ban evasion detected in AddAlias is handled, nil if it isn't detected at all.
+	evasionPolicy *EvasionPolicy
+	// evasionHandler is called for every ban evasion detected, it may be nil.
+	evasionHandler EvasionHandler
+	// escalation holds the Ladder of every offence category Escalate can be used for.
+	escalation EscalationPolicy
+	// reportCooldown is the cooldown within which a player can't report the same player again.
+	reportCooldown time.Duration
+	// presets is the catalogue of reason presets used by PunishPreset, nil if none were set.
+	presets *Presets
+	// ipv6Bits is the prefix length IPv6 addresses are grouped by.
+	ipv6Bits int
+	// identifierKeys holds the keys used to pseudonymise ip and device identifiers, the first being the current key
+	// and the others being previous keys. Identifiers are stored as they are if it is empty.
+	identifierKeys [][]byte
+	lock           sync.RWMutex
+
+	// indexed holds the case IDs of all punishments added to the index of the provider since the Registry was
+	// created, so that they aren't indexed again on every save.
+	indexed   map[string]struct{}
+	indexLock sync.Mutex
+
+	// events delivers the events emitted by the Registry to the listeners subscribed.
+	events *dispatcher
 }
 
 // New returns a new punishment handler.
 func New(provider Provider, aliasHandler AliasHandler) Registry {
 	return Registry{
-		provider:     provider,
-		aliasHandler: aliasHandler,
-		punishments:  map[string]map[any]Container{},
+		provider:       provider,
+		aliasHandler:   aliasHandler,
+		punishments:    map[Key]Container{},
+		ipv6Bits:       DefaultIpv6PrefixLength,
+		reportCooldown: DefaultReportCooldown,
+		indexed:        map[string]struct{}{},
+		events:         newDispatcher(),
 	}
 }
 
-// AddAlias will register an alias onto ip and device as well as call the aliasHandler.
+// AddAlias will register an alias onto ip and device, record the ip and device on the xbox of the user as well as call
+// the aliasHandler. If ban evasion detection is enabled, the ip and device are checked for bans if the user wasn't
+// seen on them before. The ip is normalized first, invalid ip addresses are not registered. If identifier keys are
+// set, the ip and device are only recorded by their pseudonyms, even if they look like one already.
 func (r *Registry) AddAlias(username, ip, device, xuid string, data ...any) bool {
-	ipc, err := r.Ip(ip)
-	if err == nil {
-		ipc.AddAlias(Alias{
-			Username: username,
-			Xuid:     xuid,
-		})
-	}
-	dev, err := r.Device(device)
-	if err == nil {
-		dev.AddAlias(Alias{
-			Username: username,
-			Xuid:     xuid,
-		})
+	ipKey, ipErr := r.clientKey(IpKey(ip))
+	deviceKey, deviceErr := r.clientKey(DeviceKey(device))
+	if x, err := r.Xbox(xuid); err == nil {
+		if ipErr == nil {
+			x.AddIp(ipKey.ID)
+		}
+		if deviceErr == nil {
+			x.AddDevice(deviceKey.ID)
+		}
+	}
+	alias := Alias{
+		Username: username,
+		Xuid:     xuid,
+	}
+	var fresh []aliasHolder
+	if ipErr == nil {
+		c, err := r.load(IpKey(ip), ipKey)
+		if ipc, ok := c.(*Ip); err == nil && ok && ipc.AddAlias(alias) {
+			fresh = append(fresh, aliasHolder{key: ipKey, container: ipc})
+		}
+	}
+	if deviceErr == nil {
+		c, err := r.load(DeviceKey(device), deviceKey)
+		if dev, ok := c.(*Device); err == nil && ok && dev.AddAlias(alias) {
+			fresh = append(fresh, aliasHolder{key: deviceKey, container: dev})
+		}
+	}
+	for _, h := range fresh {
+		r.emit(Event{Type: EventAliasAdded, Key: h.key, Alias: alias})
+	}
+	if xuid != "" && len(fresh) > 0 {
+		r.detectEvasion(username, xuid, fresh)
 	}
 	if r.aliasHandler != nil {
-		return r.aliasHandler(username, ip, device, xuid, data)
+		return r.aliasHandler(username, ip, device, xuid, data...)
 	}
 	return true
 }
 
 // Xbox attempts to load an xbox object and return it.
 func (r *Registry) Xbox(xuid string) (*Xbox, error) {
-	xboxc, err := r.Load(XuidIdentifier, xuid)
+	return Get[*Xbox](r, XuidKey(xuid))
+}
+
+// Ip attempts to load an ip object and return it. The ip address is normalized using NormalizeIp first, so that
+// addresses with ports and IPv6 addresses within the same prefix share a single object.
+func (r *Registry) Ip(ip string) (*Ip, error) {
+	return Get[*Ip](r, IpKey(ip))
+}
+
+// Device attempts to load a device object and return it.
+func (r *Registry) Device(device string) (*Device, error) {
+	return Get[*Device](r, DeviceKey(device))
+}
+
+// Punish issues a punishment of the Kind passed on the container with the Key passed. The punishment is always given
+// a new case ID, so that the same punishment issued on several containers can be told apart. The punishment issued is
+// returned.
+func (r *Registry) Punish(key Key, k Kind, p Punishment) (Punishment, error) {
+	c, err := r.punishable(key)
 	if err != nil {
-		return nil, err
+		return Punishment{}, err
 	}
-	xbox, ok := (xboxc).(*Xbox)
-	if !ok {
-		return nil, fmt.Errorf("container type is not of type Xbox")
+	stored, _ := r.key(key)
+	return r.punish(stored, c, k, p)
+}
+
+// punish issues a punishment of the Kind passed with a new case ID on the container passed, which is stored by the
+// Key passed, and returns it.
+func (r *Registry) punish(key Key, c Punishable, k Kind, p Punishment) (Punishment, error) {
+	p.ID = newCaseID()
+	return p, r.issue(key, c, k, p)
+}
+
+// issue issues a punishment of the Kind passed on the container passed, which is stored by the Key passed, and emits
+// an EventIssued event. The case ID of the punishment must be new.
+func (r *Registry) issue(key Key, c Punishable, k Kind, p Punishment) error {
+	if err := c.Punish(k, p); err != nil {
+		return err
 	}
-	return xbox, nil
+	r.emit(Event{Type: EventIssued, Key: key, Kind: k, Punishment: p})
+	return nil
 }
 
-// Ip attempts to load an ip object and return it.
-func (r *Registry) Ip(ip string) (*Ip, error) {
-	ipco, err := r.Load(IpIdentifier, ip)
+// Lift lifts every active punishment of the Kind passed on the container with the Key passed. by and reason are
+// recorded on the lifted punishments. ErrNotActive is returned if the container has no active punishment of the Kind.
+func (r *Registry) Lift(key Key, k Kind, by, reason string) error {
+	c, err := r.punishable(key)
+	if err != nil {
+		return err
+	}
+	active := c.Active(k)
+	if err := c.Lift(k, by, reason); err != nil {
+		return err
+	}
+	stored, _ := r.key(key)
+	for _, p := range active {
+		r.emitLifted(stored, c, p.ID)
+	}
+	return nil
+}
+
+// emitLifted emits an EventLifted event for the punishment with the case ID passed, which was just lifted on the
+// container passed. Events of range bans hold the CIDR block banned.
+func (r *Registry) emitLifted(key Key, c caseHolder, id string) {
+	e, ok := c.Case(id)
+	if !ok || !e.Punishment.Lifted() {
+		return
+	}
+	event := Event{Type: EventLifted, Key: key, Kind: e.Kind, Punishment: e.Punishment}
+	if ranges, ok := c.(*Ranges); ok {
+		event.Range, _ = ranges.prefix(id)
+	}
+	r.emit(event)
+}
+
+// Unban lifts the current ban of the container with the Key passed. by and reason are recorded on the lifted ban.
+// ErrNotBanned is returned if the container has no active ban.
+func (r *Registry) Unban(key Key, by, reason string) error {
+	if err := r.Lift(key, KindBan, by, reason); errors.Is(err, ErrNotActive) {
+		return ErrNotBanned
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Unmute lifts the current mute of the container with the Key passed. by and reason are recorded on the lifted mute.
+// ErrNotMuted is returned if the container has no active mute.
+func (r *Registry) Unmute(key Key, by, reason string) error {
+	if err := r.Lift(key, KindMute, by, reason); errors.Is(err, ErrNotActive) {
+		return ErrNotMuted
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// punishable loads the container with the Key passed and returns it if punishments can be issued on it.
+func (r *Registry) punishable(key Key) (Punishable, error) {
+	c, err := r.Load(key)
 	if err != nil {
 		return nil, err
 	}
-	ipc, ok := (ipco).(*Ip)
+	p, ok := c.(Punishable)
 	if !ok {
-		return nil, fmt.Errorf("container type is not of type Ip")
+		return nil, fmt.Errorf("container type %T can't hold punishments", c)
 	}
-	return ipc, nil
+	return p, nil
 }
 
-// Device attempts to load a device object and return it.
-func (r *Registry) Device(device string) (*Device, error) {
-	devc, err := r.Load(DeviceIdentifier, device)
+// Warn records a warning given to the user with the xuid passed.
+func (r *Registry) Warn(xuid string, w Punishment) error {
+	x, err := r.Xbox(xuid)
+	if err != nil {
+		return err
+	}
+	_, err = r.punish(XuidKey(xuid), x, KindWarning, w)
+	return err
+}
+
+// Kick records a kick received by the user with the xuid passed. It doesn't disconnect the user, which is left to the
+// server.
+func (r *Registry) Kick(xuid string, k Punishment) error {
+	x, err := r.Xbox(xuid)
+	if err != nil {
+		return err
+	}
+	_, err = r.punish(XuidKey(xuid), x, KindKick, k)
+	return err
+}
+
+// Record returns every punishment the user with the xuid passed has received on their xbox, of any kind, ordered by
+// the time they were issued.
+func (r *Registry) Record(xuid string) ([]Entry, error) {
+	x, err := r.Xbox(xuid)
 	if err != nil {
 		return nil, err
 	}
-	dev, ok := (devc).(*Device)
-	if !ok {
-		return nil, fmt.Errorf("container type is not of type Device")
+	return x.Record(), nil
+}
+
+// Load will attempt to load a Container from the provider and return it. The Key passed is validated first. IDs of
+// IpIdentifier keys are normalized using NormalizeIp, and IDs of IpIdentifier and DeviceIdentifier keys are replaced
+// by their pseudonyms if identifier keys are set.
+func (r *Registry) Load(key Key) (Container, error) {
+	stored, err := r.key(key)
+	if err != nil {
+		return nil, err
 	}
-	return dev, nil
+	return r.load(key, stored)
 }
 
-// Load will attempt to load a Container from the provider and return it. It takes in a punishment type and a user
-// identifier.
-func (r *Registry) Load(ptype string, identifier any) (Container, error) {
+// load loads the container stored by the Key stored, which the raw Key passed was resolved to, migrating containers
+// stored by previous pseudonyms of the raw Key into it.
+func (r *Registry) load(key, stored Key) (Container, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	if _, ok := r.punishments[ptype]; !ok {
-		r.punishments[ptype] = map[any]Container{}
-	}
-
-	if c, ok := r.punishments[ptype][identifier]; ok {
+	if c, ok := r.punishments[stored]; ok {
 		return c, nil
 	}
-	container, err := r.provider.Load(ptype, identifier)
+	container, err := r.provider.Load(stored)
 	if err != nil {
 		return nil, fmt.Errorf("unable to load container: %w", err)
 	}
-	r.punishments[ptype][identifier] = container
-	return r.punishments[ptype][identifier], nil
+	if stored != key {
+		if _, err := r.migrateKeys(key, container); err != nil {
+			return nil, fmt.Errorf("unable to migrate container: %w", err)
+		}
+	}
+	r.punishments[stored] = container
+	r.emit(Event{Type: EventLoaded, Key: stored})
+	return container, nil
+}
+
+// loadedContainer is a container loaded in a Registry along with the Key it's stored by.
+type loadedContainer struct {
+	key       Key
+	container Container
+}
+
+// loaded returns all containers currently loaded in the Registry.
+func (r *Registry) loaded() []loadedContainer {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	containers := make([]loadedContainer, 0, len(r.punishments))
+	for k, c := range r.punishments {
+		containers = append(containers, loadedContainer{key: k, container: c})
+	}
+	return containers
 }
 
 // Save attempts to save all the data within the punishment Registry.
@@ -128,19 +312,23 @@ func (r *Registry) Save() error {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 	var err error
-	err = nil
-	for ptype, punishments := range r.punishments {
-		for id, punishment := range punishments {
-			er := r.provider.Save(ptype, id, punishment.Data())
-			if err == nil && er != nil {
-				err = fmt.Errorf("error saving punishment type: %v identifier %v: %w", ptype, id, er)
-			}
+	for k, punishment := range r.punishments {
+		er := r.provider.Save(k, punishment.Data())
+		if er == nil {
+			r.emit(Event{Type: EventSaved, Key: k})
+			er = r.index(k, punishment)
+		}
+		if err == nil && er != nil {
+			err = fmt.Errorf("error saving punishment type: %v identifier %v: %w", k.Type, k.ID, er)
 		}
 	}
 	return err
 }
 
-// Close ...
+// Close saves the Registry and delivers the events still queued to the listeners subscribed. Events emitted after
+// Close are dropped. It must not be called from a Listener.
 func (r *Registry) Close() error {
-	return r.Save()
+	err := r.Save()
+	r.events.close()
+	return err
 }
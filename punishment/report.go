@@ -0,0 +1,317 @@
+package punishment
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/maps"
+	"sync"
+	"time"
+)
+
+// ReportIdentifier is the punishment type of Reports containers, which are stored by the xuid of the player reported.
+const ReportIdentifier = "report"
+
+// DefaultReportCooldown is the cooldown within which a player can't report the same player again, unless changed
+// using SetReportCooldown.
+const DefaultReportCooldown = 5 * time.Minute
+
+var (
+	// ErrReportCooldown is returned when a player reports the same player again within the report cooldown.
+	ErrReportCooldown = errors.New("already reported recently")
+	// ErrReportNotFound is returned when a report can't be found.
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportClosed is returned when claiming, resolving or escalating a report that was already resolved or
+	// escalated.
+	ErrReportClosed = errors.New("report already closed")
+	// ErrReportClaimed is returned when claiming a report that was already claimed by someone else.
+	ErrReportClaimed = errors.New("report already claimed")
+)
+
+// ReportStatus is the state a Report is in.
+type ReportStatus string
+
+const (
+	// ReportOpen is the status of reports that no staff has picked up yet.
+	ReportOpen ReportStatus = "open"
+	// ReportClaimed is the status of reports that a member of staff is looking into.
+	ReportClaimed ReportStatus = "claimed"
+	// ReportResolved is the status of reports that were resolved without punishing the player reported.
+	ReportResolved ReportStatus = "resolved"
+	// ReportEscalated is the status of reports that were escalated into a punishment.
+	ReportEscalated ReportStatus = "escalated"
+)
+
+// Report is a report a player filed against another player.
+type Report struct {
+	// ID is the unique identifier of the report.
+	ID string `json:"id"`
+	// Target is the xuid of the player reported.
+	Target string `json:"target"`
+	// Reporter is the xuid of the player that filed the report.
+	Reporter string `json:"reporter"`
+	// Category is the offence category the player was reported for.
+	Category string `json:"category"`
+	// Message is the message the reporter filed the report with.
+	Message string `json:"message"`
+	// Context holds context of the server at the time of the report, such as the server name or the world and
+	// position of the players.
+	Context map[string]string `json:"context,omitempty"`
+	// Time is the time at which the report was filed.
+	Time time.Time `json:"time"`
+	// Status is the current status of the report.
+	Status ReportStatus `json:"status"`
+	// ClaimedBy is the name of the member of staff that claimed the report, empty if it was never claimed.
+	ClaimedBy string `json:"claimed_by,omitempty"`
+	// ClaimedAt is the time at which the report was claimed, it's irrelevant unless ClaimedBy is set.
+	ClaimedAt time.Time `json:"claimed_at"`
+	// ClosedBy is the name of the member of staff that resolved or escalated the report.
+	ClosedBy string `json:"closed_by,omitempty"`
+	// ClosedAt is the time at which the report was resolved or escalated.
+	ClosedAt time.Time `json:"closed_at"`
+	// Resolution is the reason given for resolving the report the way it was.
+	Resolution string `json:"resolution,omitempty"`
+	// Case is the case ID of the punishment the report was escalated into, empty if it wasn't.
+	Case string `json:"case,omitempty"`
+}
+
+// Closed returns whether the report was resolved or escalated.
+func (r Report) Closed() bool {
+	return r.Status == ReportResolved || r.Status == ReportEscalated
+}
+
+// clone returns a copy of the report that doesn't share its Context with it.
+func (r Report) clone() Report {
+	if r.Context != nil {
+		r.Context = maps.Clone(r.Context)
+	}
+	return r
+}
+
+// cloneReports returns a copy of the reports passed that shares no memory with them.
+func cloneReports(reports []Report) []Report {
+	if reports == nil {
+		return nil
+	}
+	c := make([]Report, len(reports))
+	for i, report := range reports {
+		c[i] = report.clone()
+	}
+	return c
+}
+
+// Reports holds every report filed against a single player.
+type Reports struct {
+	// reports holds every report, in the order they were filed.
+	reports []Report
+
+	lock sync.RWMutex
+}
+
+// ReportsData is a data representation of Reports used for loading and saving reports.
+type ReportsData struct {
+	// Reports represents reports within Reports.
+	Reports []Report `json:"reports"`
+}
+
+func (d *ReportsData) Container() Container {
+	return &Reports{reports: d.Reports}
+}
+
+// File files the report passed. ErrReportCooldown is returned if the reporter filed another report within the
+// cooldown passed.
+func (r *Reports) File(report Report, cooldown time.Duration) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, o := range r.reports {
+		if o.Reporter == report.Reporter && report.Time.Sub(o.Time) < cooldown {
+			return ErrReportCooldown
+		}
+	}
+	report.Status = ReportOpen
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+// Report returns the report with the ID passed, and false if there is no such report.
+func (r *Reports) Report(id string) (Report, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, report := range r.reports {
+		if report.ID == id {
+			return report.clone(), true
+		}
+	}
+	return Report{}, false
+}
+
+// All returns every report, in the order they were filed.
+func (r *Reports) All() []Report {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return cloneReports(r.reports)
+}
+
+// Open returns every report that wasn't resolved or escalated yet, in the order they were filed.
+func (r *Reports) Open() []Report {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var open []Report
+	for _, report := range r.reports {
+		if !report.Closed() {
+			open = append(open, report.clone())
+		}
+	}
+	return open
+}
+
+// Claim claims the report with the ID passed for the member of staff passed. ErrReportClaimed is returned if someone
+// else claimed it already.
+func (r *Reports) Claim(id, by string) (Report, error) {
+	return r.update(id, func(report *Report) error {
+		if report.Status == ReportClaimed && report.ClaimedBy != by {
+			return ErrReportClaimed
+		}
+		report.Status, report.ClaimedBy, report.ClaimedAt = ReportClaimed, by, time.Now()
+		return nil
+	})
+}
+
+// Resolve resolves the report with the ID passed without punishing the player reported, recording who resolved it
+// and why.
+func (r *Reports) Resolve(id, by, resolution string) (Report, error) {
+	return r.update(id, func(report *Report) error {
+		report.Status, report.ClosedBy, report.ClosedAt, report.Resolution = ReportResolved, by, time.Now(), resolution
+		return nil
+	})
+}
+
+// escalate marks the report with the ID passed as escalated into the punishment returned by issue. issue is called
+// with the report while it is locked, so that the report can't be closed by anyone else in the meantime, and the
+// report is left untouched if it returns an error.
+func (r *Reports) escalate(id, by string, issue func(report Report) (Punishment, error)) (Punishment, Report, error) {
+	var p Punishment
+	report, err := r.update(id, func(report *Report) (err error) {
+		if p, err = issue(*report); err != nil {
+			return err
+		}
+		report.Status, report.ClosedBy, report.ClosedAt, report.Resolution = ReportEscalated, by, time.Now(), p.Reason()
+		report.Case = p.ID
+		return nil
+	})
+	if err != nil {
+		return Punishment{}, Report{}, err
+	}
+	return p, report, nil
+}
+
+// update calls f with the report with the ID passed and returns the updated report. ErrReportNotFound is returned if
+// there is no such report, and ErrReportClosed if it was closed already.
+func (r *Reports) update(id string, f func(report *Report) error) (Report, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i := range r.reports {
+		report := &r.reports[i]
+		if report.ID != id {
+			continue
+		}
+		if report.Closed() {
+			return Report{}, ErrReportClosed
+		}
+		if err := f(report); err != nil {
+			return Report{}, err
+		}
+		return report.clone(), nil
+	}
+	return Report{}, ErrReportNotFound
+}
+
+// Data returns the data representation of Reports.
+func (r *Reports) Data() DataHolder {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return &ReportsData{Reports: cloneReports(r.reports)}
+}
+
+// SetReportCooldown sets the cooldown within which a player can't report the same player again.
+func (r *Registry) SetReportCooldown(cooldown time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reportCooldown = cooldown
+}
+
+// Reports loads the Reports filed against the player with the xuid passed.
+func (r *Registry) Reports(target string) (*Reports, error) {
+	return Get[*Reports](r, Key{Type: ReportIdentifier, ID: target})
+}
+
+// FileReport files a report by the player with the xuid reporter against the player with the xuid target and
+// returns it. ErrReportCooldown is returned if the reporter reported the target within the report cooldown.
+func (r *Registry) FileReport(target, reporter, category, message string, context map[string]string) (Report, error) {
+	if target == reporter {
+		return Report{}, fmt.Errorf("players can't report themselves")
+	}
+	r.lock.RLock()
+	cooldown := r.reportCooldown
+	r.lock.RUnlock()
+	reports, err := r.Reports(target)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{
+		ID:       newCaseID(),
+		Target:   target,
+		Reporter: reporter,
+		Category: category,
+		Message:  message,
+		Context:  context,
+		Time:     time.Now(),
+		Status:   ReportOpen,
+	}
+	if err := reports.File(report, cooldown); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// ClaimReport claims the report with the ID passed against the player with the xuid target for the member of staff
+// passed.
+func (r *Registry) ClaimReport(target, id, by string) (Report, error) {
+	reports, err := r.Reports(target)
+	if err != nil {
+		return Report{}, err
+	}
+	return reports.Claim(id, by)
+}
+
+// ResolveReport resolves the report with the ID passed against the player with the xuid target without punishing
+// them.
+func (r *Registry) ResolveReport(target, id, by, resolution string) (Report, error) {
+	reports, err := r.Reports(target)
+	if err != nil {
+		return Report{}, err
+	}
+	return reports.Resolve(id, by, resolution)
+}
+
+// EscalateReport escalates the report with the ID passed against the player with the xuid target into a punishment
+// of the Kind passed on their xbox. The punishment records the ID of the report, and the report records the case ID
+// of the punishment. The punishment issued and the escalated report are returned. No punishment is issued if the
+// report was closed already, in which case ErrReportClosed is returned, and the report is left open if issuing the
+// punishment fails.
+func (r *Registry) EscalateReport(target, id, by string, k Kind, p Punishment) (Punishment, Report, error) {
+	reports, err := r.Reports(target)
+	if err != nil {
+		return Punishment{}, Report{}, err
+	}
+	x, err := r.Xbox(target)
+	if err != nil {
+		return Punishment{}, Report{}, err
+	}
+	return reports.escalate(id, by, func(report Report) (Punishment, error) {
+		if p.Category == "" {
+			p.Category = report.Category
+		}
+		p.Report = report.ID
+		return r.punish(XuidKey(target), x, k, p)
+	})
+}
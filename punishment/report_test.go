@@ -0,0 +1,125 @@
+package punishment
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileReportCooldown(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	if _, err := r.FileReport("target", "reporter", "cheating", "flying", map[string]string{"server": "hub"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.FileReport("target", "reporter", "chat", "spamming", nil); !errors.Is(err, ErrReportCooldown) {
+		t.Fatalf("expected ErrReportCooldown, got %v", err)
+	}
+	if _, err := r.FileReport("target", "other", "cheating", "flying", nil); err != nil {
+		t.Fatalf("expected other reporters not to be limited, got %v", err)
+	}
+	if _, err := r.FileReport("target", "target", "cheating", "", nil); err == nil {
+		t.Fatalf("expected players not to be able to report themselves")
+	}
+	r.SetReportCooldown(0)
+	if _, err := r.FileReport("target", "reporter", "chat", "spamming", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reports, _ := r.Reports("target")
+	if len(reports.All()) != 3 || len(reports.Open()) != 3 {
+		t.Fatalf("expected 3 open reports, got %v", reports.All())
+	}
+}
+
+func TestReportWorkflow(t *testing.T) {
+	p := newMemoryProvider()
+	r := New(p, nil)
+	report, _ := r.FileReport("target", "reporter", "cheating", "flying", nil)
+	if _, err := r.ClaimReport("target", report.ID, "staff"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.ClaimReport("target", report.ID, "other"); !errors.Is(err, ErrReportClaimed) {
+		t.Fatalf("expected ErrReportClaimed, got %v", err)
+	}
+	ban, escalated, err := r.EscalateReport("target", report.ID, "staff", KindBan, NewTemporary(time.Hour, "flying", "staff"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if escalated.Status != ReportEscalated || escalated.Case != ban.ID || ban.Report != report.ID || ban.Category != "cheating" {
+		t.Fatalf("expected report and punishment to link to each other, got %+v and %+v", escalated, ban)
+	}
+	x, _ := r.Xbox("target")
+	if x.CurrentBan().Report != report.ID {
+		t.Fatalf("expected target to be banned for the report")
+	}
+	if _, err := r.ResolveReport("target", report.ID, "staff", "done"); !errors.Is(err, ErrReportClosed) {
+		t.Fatalf("expected ErrReportClosed, got %v", err)
+	}
+	if _, err := r.ResolveReport("target", "unknown", "staff", "done"); !errors.Is(err, ErrReportNotFound) {
+		t.Fatalf("expected ErrReportNotFound, got %v", err)
+	}
+
+	if err := r.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r = New(p, nil)
+	reports, _ := r.Reports("target")
+	if stored, ok := reports.Report(report.ID); !ok || stored.Case != ban.ID {
+		t.Fatalf("expected report to be persisted, got %+v", stored)
+	}
+}
+
+func TestEscalateReportOnce(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	report, _ := r.FileReport("target", "reporter", "cheating", "flying", nil)
+	if _, _, err := r.EscalateReport("target", report.ID, "staff", "unknown", NewPunishment("flying", "staff")); err == nil {
+		t.Fatalf("expected escalating into an unknown kind to fail")
+	}
+	reports, _ := r.Reports("target")
+	if stored, _ := reports.Report(report.ID); stored.Closed() {
+		t.Fatalf("expected report to stay open if the punishment can't be issued, got %+v", stored)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := r.EscalateReport("target", report.ID, "staff", KindWarning, NewPunishment("flying", "staff"))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	var escalated int
+	for err := range errs {
+		if err == nil {
+			escalated++
+		} else if !errors.Is(err, ErrReportClosed) {
+			t.Fatalf("expected ErrReportClosed, got %v", err)
+		}
+	}
+	x, _ := r.Xbox("target")
+	if h := x.History(KindWarning); escalated != 1 || len(h) != 1 {
+		t.Fatalf("expected the report to be escalated once, got %v escalations and %v warnings", escalated, len(h))
+	}
+	if stored, _ := reports.Report(report.ID); stored.Case != x.History(KindWarning)[0].ID {
+		t.Fatalf("expected report to link to the warning, got %+v", stored)
+	}
+}
+
+func TestClaimDoesNotChangeSavedData(t *testing.T) {
+	r := &Reports{}
+	_ = r.File(Report{ID: "report", Reporter: "reporter", Context: map[string]string{"server": "lobby"}}, 0)
+	d := r.Data().(*ReportsData)
+	all := r.All()
+	_, _ = r.Claim("report", "staff")
+	if d.Reports[0].Status != ReportOpen || all[0].Status != ReportOpen {
+		t.Fatalf("expected data handed out earlier not to change")
+	}
+	d.Reports[0].Context["server"] = "survival"
+	if report, _ := r.Report("report"); report.Context["server"] != "lobby" {
+		t.Fatalf("expected context of data handed out not to be shared")
+	}
+}
@@ -0,0 +1,115 @@
+package punishment
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ExpiryHandler is called by a Scheduler for every punishment it lifts because it expired. key is the Key of the
+// container the punishment was lifted from.
+type ExpiryHandler func(key Key, kind Kind, p Punishment)
+
+// Scheduler periodically walks all containers loaded in a Registry and moves expired bans and mutes, including range
+// bans, into their history.
+type Scheduler struct {
+	registry *Registry
+	interval time.Duration
+	// clock returns the time that punishments are checked against when archiving them.
+	clock func() time.Time
+	// handler is called for every punishment that is lifted, it may be nil.
+	handler ExpiryHandler
+
+	mu      sync.Mutex
+	started bool
+	once    sync.Once
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// NewScheduler returns a new Scheduler that checks the containers of the registry passed every interval once started.
+// clock is used to get the current time, if nil time.Now is used. It only controls when the Scheduler moves expired
+// punishments into the history: whether a punishment is in effect, as reported by Active, Current and verdicts, is
+// always checked against time.Now. handler is called for every punishment that is lifted and may be nil. An error is
+// returned if the interval isn't positive.
+func NewScheduler(registry *Registry, interval time.Duration, clock func() time.Time, handler ExpiryHandler) (*Scheduler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("scheduler interval must be positive, got %v", interval)
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Scheduler{
+		registry: registry,
+		interval: interval,
+		clock:    clock,
+		handler:  handler,
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start starts checking for expired punishments in the background every interval, until Close is called. Calling
+// Start more than once has no effect.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go func() {
+		defer close(s.done)
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				s.Tick()
+			case <-s.closing:
+				return
+			}
+		}
+	}()
+}
+
+// Tick checks all loaded containers for expired punishments once, moving them into the history of their container.
+// An EventExpired event is emitted and the handler of the Scheduler is called for each punishment lifted, after the
+// container has been updated.
+func (s *Scheduler) Tick() {
+	now := s.clock()
+	for _, l := range s.registry.loaded() {
+		switch c := l.container.(type) {
+		case Punishable:
+			for _, e := range c.Expire(now) {
+				s.expired(Event{Type: EventExpired, Key: l.key, Kind: e.Kind, Punishment: e.Punishment})
+			}
+		case *Ranges:
+			for _, rb := range c.Expire(now) {
+				s.expired(Event{Type: EventExpired, Key: l.key, Range: rb.Prefix, Kind: KindBan, Punishment: rb.Ban})
+			}
+		}
+	}
+}
+
+// expired emits the EventExpired event passed and calls the handler of the Scheduler with the punishment expired.
+func (s *Scheduler) expired(e Event) {
+	s.registry.emit(e)
+	if s.handler != nil {
+		s.handler(e.Key, e.Kind, e.Punishment)
+	}
+}
+
+// Close stops the Scheduler and waits for a running check to finish.
+func (s *Scheduler) Close() error {
+	s.once.Do(func() {
+		close(s.closing)
+	})
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if started {
+		<-s.done
+	}
+	return nil
+}
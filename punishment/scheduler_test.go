@@ -0,0 +1,157 @@
+package punishment
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// memoryProvider is a Provider that keeps all data in memory.
+type memoryProvider struct {
+	data  map[string]map[string]DataHolder
+	cases map[string]Key
+}
+
+func newMemoryProvider() *memoryProvider {
+	return &memoryProvider{data: map[string]map[string]DataHolder{}, cases: map[string]Key{}}
+}
+
+func (m *memoryProvider) Load(key Key) (Container, error) {
+	if d, ok := m.data[key.Type][key.ID]; ok {
+		return d.Container(), nil
+	}
+	d, ok := NewDataHolder(key.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown punishment type %v", key.Type)
+	}
+	return d.Container(), nil
+}
+
+func (m *memoryProvider) Save(key Key, data DataHolder) error {
+	if _, ok := m.data[key.Type]; !ok {
+		m.data[key.Type] = map[string]DataHolder{}
+	}
+	m.data[key.Type][key.ID] = data
+	return nil
+}
+
+func (m *memoryProvider) Delete(key Key) error {
+	delete(m.data[key.Type], key.ID)
+	return nil
+}
+
+func (m *memoryProvider) ForEach(ptype string, f func(key Key, c Container) error) error {
+	for id, d := range m.data[ptype] {
+		if err := f(Key{Type: ptype, ID: id}, d.Container()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryProvider) Index(id string, key Key) error {
+	m.cases[id] = key
+	return nil
+}
+
+func (m *memoryProvider) Lookup(id string) (Key, bool, error) {
+	key, ok := m.cases[id]
+	return key, ok, nil
+}
+
+func TestSchedulerArchivesExpiredPunishments(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	x, err := r.Xbox("xuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ban := NewTemporary(time.Hour, "hacking", "staff")
+	mute := NewTemporary(time.Minute, "spam", "staff")
+	x.Ban(ban)
+	x.Mute(mute)
+
+	now := time.Now()
+	var lifted []Kind
+	s, err := NewScheduler(&r, time.Second, func() time.Time { return now }, func(key Key, kind Kind, p Punishment) {
+		if key != XuidKey("xuid") {
+			t.Fatalf("unexpected container %v", key)
+		}
+		lifted = append(lifted, kind)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Tick()
+	if len(lifted) != 0 {
+		t.Fatalf("expected nothing to be lifted yet, got %v", lifted)
+	}
+
+	now = now.Add(2 * time.Minute)
+	s.Tick()
+	if len(lifted) != 1 || lifted[0] != KindMute {
+		t.Fatalf("expected the mute to be lifted, got %v", lifted)
+	}
+	if h := x.MuteHistory(); len(h) != 1 || !sameIssue(h[0], mute) {
+		t.Fatalf("expected mute to be archived, got %v", h)
+	}
+	if !x.CurrentMute().Empty() {
+		t.Fatalf("expected current mute to be cleared, got %v", x.CurrentMute())
+	}
+	if !sameIssue(x.CurrentBan(), ban) {
+		t.Fatalf("expected ban to remain, got %v", x.CurrentBan())
+	}
+
+	now = now.Add(2 * time.Hour)
+	s.Tick()
+	if len(lifted) != 2 || lifted[1] != KindBan {
+		t.Fatalf("expected the ban to be lifted, got %v", lifted)
+	}
+	if h := x.BanHistory(); len(h) != 1 || !sameIssue(h[0], ban) {
+		t.Fatalf("expected ban to be archived, got %v", h)
+	}
+	_ = s.Close()
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := NewScheduler(&r, interval, nil, nil); err == nil {
+			t.Fatalf("expected interval %v to be refused", interval)
+		}
+	}
+}
+
+func TestSchedulerArchivesExpiredRangeBans(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	var expired []Event
+	r.Subscribe(func(e Event) {
+		expired = append(expired, e)
+	}, EventExpired)
+	ban, err := r.BanRange("10.0.0.0/8", NewTemporary(time.Minute, "proxy", "staff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Add(time.Hour)
+	var lifted []Key
+	s, err := NewScheduler(&r, time.Second, func() time.Time { return now }, func(key Key, kind Kind, p Punishment) {
+		lifted = append(lifted, key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Tick()
+	r.events.wait()
+
+	ranges, _ := r.Ranges()
+	if len(ranges.Bans()) != 0 || len(ranges.BanHistory()) != 1 || ranges.BanHistory()[0].Ban.ID != ban.ID {
+		t.Fatalf("expected range ban to be archived, got %v and %v", ranges.Bans(), ranges.BanHistory())
+	}
+	if len(lifted) != 1 || lifted[0] != rangesKey {
+		t.Fatalf("expected the handler to be called for the range ban, got %v", lifted)
+	}
+	if len(expired) != 1 || expired[0].Range.String() != "10.0.0.0/8" || expired[0].Punishment.ID != ban.ID {
+		t.Fatalf("expected an expired event for the range ban, got %+v", expired)
+	}
+	_ = r.Close()
+}
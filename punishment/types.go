@@ -0,0 +1,106 @@
+package punishment
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Type describes a type of container, such as Xbox or Ip. Registering a Type allows a Registry to load containers of
+// it and providers to store them, without either needing to know about the Type ahead of time.
+type Type struct {
+	// Name is the punishment type of the containers, used as the Type of their Keys. It must be unique.
+	Name string
+	// New returns a new empty container of the Type, which is used for Keys that nothing has been stored for yet.
+	New func() Container
+	// Data returns a new empty DataHolder of the Type, which providers decode stored data into.
+	Data func() DataHolder
+}
+
+var (
+	// typesMu guards types.
+	typesMu sync.RWMutex
+	// types holds all registered Types by their name.
+	types = map[string]Type{}
+)
+
+func init() {
+	RegisterType(Type{Name: XuidIdentifier, New: func() Container { return &Xbox{} }, Data: func() DataHolder { return &XboxData{} }})
+	RegisterType(Type{Name: IpIdentifier, New: func() Container { return &Ip{} }, Data: func() DataHolder { return &IpData{} }})
+	RegisterType(Type{Name: DeviceIdentifier, New: func() Container { return &Device{} }, Data: func() DataHolder { return &DeviceData{} }})
+	RegisterType(Type{Name: AppealIdentifier, New: func() Container { return &Appeals{} }, Data: func() DataHolder { return &AppealsData{} }})
+	RegisterType(Type{Name: ReportIdentifier, New: func() Container { return &Reports{} }, Data: func() DataHolder { return &ReportsData{} }})
+	RegisterType(Type{Name: RangeIdentifier, New: func() Container { return &Ranges{} }, Data: func() DataHolder { return &RangesData{} }})
+}
+
+// RegisterType registers a Type of container. It is typically called from an init function of the package that
+// declares the Type. RegisterType panics if the Type has no name, New or Data, or if a Type with the same name was
+// already registered.
+func RegisterType(t Type) {
+	if t.Name == "" || t.New == nil || t.Data == nil {
+		panic(fmt.Sprintf("punishment: type %q must have a name, New and Data", t.Name))
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if _, ok := types[t.Name]; ok {
+		panic(fmt.Sprintf("punishment: type %q registered twice", t.Name))
+	}
+	types[t.Name] = t
+}
+
+// TypeByName returns the Type registered with the name passed.
+func TypeByName(name string) (Type, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	t, ok := types[name]
+	return t, ok
+}
+
+// Types returns all registered Types, sorted by name.
+func Types() []Type {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	all := make([]Type, 0, len(types))
+	for _, t := range types {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// NewDataHolder returns an empty DataHolder for the punishment type passed, which providers can decode stored data
+// into. False is returned if the punishment type is unknown.
+func NewDataHolder(ptype string) (DataHolder, bool) {
+	t, ok := TypeByName(ptype)
+	if !ok {
+		return nil, false
+	}
+	return t.Data(), true
+}
+
+// NewContainer returns an empty container of the punishment type passed, which providers return for Keys that nothing
+// has been stored for. False is returned if the punishment type is unknown.
+func NewContainer(ptype string) (Container, bool) {
+	t, ok := TypeByName(ptype)
+	if !ok {
+		return nil, false
+	}
+	return t.New(), true
+}
+
+// Get loads the container with the Key passed from the Registry and returns it as C. An error is returned if the
+// container is not of type C.
+func Get[C Container](r *Registry, key Key) (C, error) {
+	var zero C
+	c, err := r.Load(key)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := c.(C)
+	if !ok {
+		return zero, fmt.Errorf("container type %T is not of type %T", c, zero)
+	}
+	return typed, nil
+}
@@ -0,0 +1,69 @@
+package punishment
+
+import (
+	"sync"
+	"testing"
+)
+
+// clientRandomId is a custom container type used to test registering types.
+type clientRandomId struct {
+	banned bool
+}
+
+type clientRandomIdData struct {
+	Banned bool `json:"banned"`
+}
+
+func (c *clientRandomId) Data() DataHolder {
+	return &clientRandomIdData{Banned: c.banned}
+}
+
+func (d *clientRandomIdData) Container() Container {
+	return &clientRandomId{banned: d.Banned}
+}
+
+// clientRandomIdType is the Type of clientRandomId containers. It is registered once, so that the tests can be run
+// more than once in a single process.
+var (
+	clientRandomIdType = Type{
+		Name: "client_random_id",
+		New:  func() Container { return &clientRandomId{} },
+		Data: func() DataHolder { return &clientRandomIdData{} },
+	}
+	registerClientRandomId sync.Once
+)
+
+func TestRegisterType(t *testing.T) {
+	name := clientRandomIdType.Name
+	registerClientRandomId.Do(func() { RegisterType(clientRandomIdType) })
+
+	p := newMemoryProvider()
+	r := New(p, nil)
+	c, err := Get[*clientRandomId](&r, Key{Type: name, ID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.banned = true
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	r = New(p, nil)
+	c, err = Get[*clientRandomId](&r, Key{Type: name, ID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.banned {
+		t.Fatalf("expected custom container to be stored")
+	}
+	if _, err := Get[*Xbox](&r, Key{Type: name, ID: "1234"}); err == nil {
+		t.Fatalf("expected getting the wrong container type to fail")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected registering a type twice to panic")
+		}
+	}()
+	RegisterType(clientRandomIdType)
+}
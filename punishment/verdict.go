@@ -0,0 +1,120 @@
+package punishment
+
+import (
+	"net/netip"
+	"time"
+)
+
+// Verdict is the outcome of checking whether a player may do something, such as joining the server or chatting.
+type Verdict struct {
+	// Allowed is true if the player may go ahead.
+	Allowed bool
+	// Key is the Key the container that caused the denial is stored by, its Type being the layer the punishment was
+	// found on. It holds the pseudonym of ips and devices if identifier keys are set, and is empty if Allowed is true.
+	Key Key
+	// Range is the CIDR block of the range ban responsible for the denial if Key is that of the Ranges container.
+	Range netip.Prefix
+	// ByAliasHandler is true if the login was denied because the AliasHandler of the Registry returned false, rather
+	// than because of a punishment. Key and Punishment are empty if so.
+	ByAliasHandler bool
+	// Punishment is the punishment responsible for the denial.
+	Punishment Punishment
+}
+
+// Remaining returns how much longer the punishment responsible for the denial lasts. It returns 0 if the player is
+// allowed or if the punishment never expires, which can be told apart by Allowed and Punishment.Expires.
+func (v Verdict) Remaining() time.Duration {
+	if v.Allowed {
+		return 0
+	}
+	return v.Punishment.Remaining()
+}
+
+// CheckLogin registers the alias of a player joining and checks whether any of their xuid, ip or device are banned,
+// or punished with any other Kind that blocks logins, or whether their ip falls within a banned range. If more than
+// one of them is banned, the Verdict holds the ban that lasts longest, preferring xuid bans over device bans over ip
+// bans over range bans if they end at the same time. Empty identifiers, and ips that aren't valid addresses, are not
+// checked. If the player isn't punished but the AliasHandler of the Registry returns false, the login is denied with
+// a Verdict that has ByAliasHandler set.
+func (r *Registry) CheckLogin(username, xuid, ip, device string) (Verdict, error) {
+	handled := r.AddAlias(username, ip, device, xuid)
+	v, err := r.check(xuid, ip, device, func(props KindProperties) bool {
+		return props.BlocksLogin
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+	// Identifiers that aren't valid addresses can't fall within a range.
+	if addr, err := parseAddr(ip); err == nil {
+		ranges, err := r.Ranges()
+		if err != nil {
+			return Verdict{}, err
+		}
+		if rb, ok := ranges.Match(addr); ok && (v.Allowed || outlasts(rb.Ban, v.Punishment)) {
+			v = Verdict{Key: rangesKey, Range: rb.Prefix, Punishment: rb.Ban}
+		}
+	}
+	if v.Allowed && !handled {
+		v = Verdict{ByAliasHandler: true}
+	}
+	return v, nil
+}
+
+// CheckChat checks whether a player may chat, by looking for active mutes, or punishments of any other Kind that
+// blocks chatting, on their xuid, ip or device. If more than one of them is muted, the Verdict holds the mute that
+// lasts longest, preferring xuid mutes over device mutes over ip mutes if they end at the same time. Empty
+// identifiers, and ips that aren't valid addresses, are not checked.
+func (r *Registry) CheckChat(xuid, ip, device string) (Verdict, error) {
+	return r.check(xuid, ip, device, func(props KindProperties) bool {
+		return props.BlocksChat
+	})
+}
+
+// check loads the containers of the xuid, device and ip passed, in that order, and looks for active punishments of
+// every Kind blocks returns true for. The Verdict returned denies with the longest lasting of them, if any.
+func (r *Registry) check(xuid, ip, device string, blocks func(props KindProperties) bool) (Verdict, error) {
+	var kinds []Kind
+	for _, k := range Kinds() {
+		if props, _ := k.Properties(); blocks(props) {
+			kinds = append(kinds, k)
+		}
+	}
+	v := Verdict{Allowed: true}
+	for _, k := range []Key{XuidKey(xuid), DeviceKey(device), IpKey(ip)} {
+		if k.ID == "" {
+			continue
+		}
+		stored, err := r.clientKey(k)
+		if err != nil {
+			if k.Type == IpIdentifier {
+				// Identifiers that aren't valid addresses, such as "localhost", can't be stored and are skipped.
+				continue
+			}
+			return Verdict{}, err
+		}
+		c, err := r.load(k, stored)
+		if err != nil {
+			return Verdict{}, err
+		}
+		p, ok := c.(Punishable)
+		if !ok {
+			continue
+		}
+		for _, kind := range kinds {
+			current := p.Current(kind)
+			if !current.Empty() && (v.Allowed || outlasts(current, v.Punishment)) {
+				v = Verdict{Key: stored, Punishment: current}
+			}
+		}
+	}
+	return v, nil
+}
+
+// outlasts returns whether punishment a ends after punishment b. Punishments that don't expire outlast those that
+// do.
+func outlasts(a, b Punishment) bool {
+	if !a.Expires || !b.Expires {
+		return !a.Expires && b.Expires
+	}
+	return a.ExpirationTime.After(b.ExpirationTime)
+}
@@ -0,0 +1,95 @@
+package punishment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckLogin(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	v, err := r.CheckLogin("player", "xuid", "1.2.3.4", "device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Allowed {
+		t.Fatalf("expected unpunished player to be allowed, got %+v", v)
+	}
+
+	ip, _ := r.Ip("1.2.3.4")
+	if a := ip.Aliases(); len(a) != 1 || a[0].Xuid != "xuid" {
+		t.Fatalf("expected alias to be recorded, got %v", a)
+	}
+
+	x, _ := r.Xbox("xuid")
+	x.Ban(NewTemporary(time.Hour, "hacking", "staff"))
+	dev, _ := r.Device("device")
+	ban := NewPunishment("alt abuse", "staff")
+	dev.Ban(ban)
+
+	v, err = r.CheckLogin("player", "xuid", "1.2.3.4", "device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Allowed || v.Key != DeviceKey("device") || !sameIssue(v.Punishment, ban) {
+		t.Fatalf("expected the permanent device ban to deny login, got %+v", v)
+	}
+}
+
+func TestCheckChat(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	ip, _ := r.Ip("1.2.3.4")
+	ip.Mute(NewTemporary(time.Hour, "spam", "staff"))
+	x, _ := r.Xbox("xuid")
+	x.Mute(NewTemporary(2*time.Hour, "toxicity", "staff"))
+	x.Ban(NewPunishment("hacking", "staff"))
+
+	v, err := r.CheckChat("xuid", "1.2.3.4", "device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Allowed || v.Key != XuidKey("xuid") || v.Punishment.Reason() != "toxicity" {
+		t.Fatalf("expected the longest mute to deny chat, got %+v", v)
+	}
+	if d := v.Remaining(); d <= time.Hour || d > 2*time.Hour {
+		t.Fatalf("unexpected remaining time %v", d)
+	}
+
+	v, err = r.CheckChat("other", "5.6.7.8", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Allowed {
+		t.Fatalf("expected unmuted player to be allowed, got %+v", v)
+	}
+}
+
+func TestCheckInvalidIp(t *testing.T) {
+	r := New(newMemoryProvider(), nil)
+	v, err := r.CheckLogin("player", "xuid", "localhost", "device")
+	if err != nil || !v.Allowed {
+		t.Fatalf("expected player with an invalid ip to be allowed, got %+v, %v", v, err)
+	}
+
+	x, _ := r.Xbox("xuid")
+	x.Mute(NewPunishment("spam", "staff"))
+	v, err = r.CheckChat("xuid", "localhost", "device")
+	if err != nil || v.Allowed || v.Key != XuidKey("xuid") {
+		t.Fatalf("expected the xuid mute to still deny chat, got %+v, %v", v, err)
+	}
+}
+
+func TestCheckLoginAliasHandler(t *testing.T) {
+	allow := false
+	r := New(newMemoryProvider(), func(username, ip, device, xuid string, data ...any) bool {
+		return allow
+	})
+	v, err := r.CheckLogin("player", "xuid", "1.2.3.4", "device")
+	if err != nil || v.Allowed || !v.ByAliasHandler {
+		t.Fatalf("expected the alias handler to deny login, got %+v, %v", v, err)
+	}
+
+	allow = true
+	if v, _ = r.CheckLogin("player", "xuid", "1.2.3.4", "device"); !v.Allowed || v.ByAliasHandler {
+		t.Fatalf("expected player to be allowed, got %+v", v)
+	}
+}
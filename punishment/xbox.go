@@ -1,100 +1,142 @@
 package punishment
 
-import "sync"
+import (
+	"golang.org/x/exp/slices"
+	"sync"
+)
 
 // Xbox represents a specific user's punishment information such as a users bans, ipbans, reports.
 type Xbox struct {
-	// currentBan is the players current active ban, this value can be null so we use a pointer.
-	currentBan Punishment
-	// pastBans store a history of all the users past bans.
-	pastBans []Punishment
-	// currentMute is the players current active mute, this value can be null so we use a pointer.
-	currentMute Punishment
-	// pastMutes store a history of all the users past mutes.
-	pastMutes []Punishment
+	// Punishments holds every punishment the user has received.
+	Punishments
+	// ips holds every ip address this user has been seen on.
+	ips []string
+	// devices holds every device-id this user has been seen on.
+	devices []string
+	// notes holds every note staff left on the account, in the order they were left.
+	notes []AccountNote
 
 	lock sync.RWMutex
 }
 
 // XboxData holds the required data for an Xbox.
 type XboxData struct {
-	// CurrentBan represents currentBan within Xbox.
-	CurrentBan Punishment `json:"current_ban"`
-	// PastBans represents pastBans within Xbox.
-	PastBans []Punishment `json:"past_bans"`
-	// CurrentMute represents currentMute within Xbox.
-	CurrentMute Punishment `json:"current_mute"`
-	// PastMutes represents pastMutes within Xbox.
-	PastMutes []Punishment `json:"past_mutes"`
+	// Punishments represents Punishments within Xbox.
+	Punishments PunishmentsData `json:"punishments"`
+	// Ips represents ips within Xbox.
+	Ips []string `json:"ips"`
+	// Devices represents devices within Xbox.
+	Devices []string `json:"devices"`
+	// Notes represents notes within Xbox.
+	Notes []AccountNote `json:"notes,omitempty"`
+
+	legacyData
 }
 
 func (x XboxData) Container() Container {
-	return &Xbox{
-		currentBan:  x.CurrentBan,
-		pastBans:    x.PastBans,
-		currentMute: x.CurrentMute,
-		pastMutes:   x.PastMutes,
+	xbox := &Xbox{
+		ips:     x.Ips,
+		devices: x.Devices,
+		notes:   x.Notes,
 	}
+	xbox.Punishments.load(x.Punishments, x.legacyData)
+	return xbox
 }
 
-// Banned returns whether the current holder is banned or not.
-func (x *Xbox) Banned() bool {
-	x.lock.RLock()
-	defer x.lock.RUnlock()
-	return x.currentBan != Punishment{}
+// AddIp records an ip address the user has been seen on, it will return true if it managed to add it and false if
+// it was already recorded.
+func (x *Xbox) AddIp(ip string) bool {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if !slices.Contains(x.ips, ip) {
+		x.ips = append(x.ips, ip)
+		return true
+	}
+	return false
 }
 
-// CurrentBan returns the users current ban.
-func (x *Xbox) CurrentBan() Punishment {
+// Ips returns all the ip addresses the user has been seen on.
+func (x *Xbox) Ips() []string {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
-	return x.currentBan
+	return x.ips
 }
 
-// Ban adds a ban to a users current ban, it moves their previous current ban to their pastBans.
-func (x *Xbox) Ban(b Punishment) {
+// normalizeIps replaces every ip recorded with the key returned by normalize, dropping duplicates. ips that can't be
+// normalized are kept as they are.
+func (x *Xbox) normalizeIps(normalize func(ip string) (string, error)) {
 	x.lock.Lock()
 	defer x.lock.Unlock()
-	x.pastBans = append(x.pastBans, x.currentBan)
-	x.currentBan = b
+	x.ips = replaceIdentifiers(x.ips, func(ip string) (string, bool) {
+		key, err := normalize(ip)
+		return key, err == nil
+	})
 }
 
-// BanHistory returns the BanHistory for the user, instead of returning a ban it returns a []BanData as it's meant to
-// be used for reading bans only.
-func (x *Xbox) BanHistory() []Punishment {
-	x.lock.RLock()
-	defer x.lock.RUnlock()
-	return x.pastBans
+// rekey replaces every ip and device recorded that is a key of ips or devices respectively with its value, dropping
+// duplicates.
+func (x *Xbox) rekey(ips, devices map[string]string) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.ips = replaceIdentifiers(x.ips, func(ip string) (string, bool) {
+		key, ok := ips[ip]
+		return key, ok
+	})
+	x.devices = replaceIdentifiers(x.devices, func(device string) (string, bool) {
+		key, ok := devices[device]
+		return key, ok
+	})
 }
 
-// Muted returns whether the current holder is banned or not.
-func (x *Xbox) Muted() bool {
+// references returns whether any ip or device recorded is a key of ips or devices respectively.
+func (x *Xbox) references(ips, devices map[string]string) bool {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
-	return x.currentBan != Punishment{}
+	for _, ip := range x.ips {
+		if _, ok := ips[ip]; ok {
+			return true
+		}
+	}
+	for _, device := range x.devices {
+		if _, ok := devices[device]; ok {
+			return true
+		}
+	}
+	return false
 }
 
-// CurrentMute returns the users current ban.
-func (x *Xbox) CurrentMute() Punishment {
-	x.lock.RLock()
-	defer x.lock.RUnlock()
-	return x.currentBan
+// replaceIdentifiers returns the identifiers passed with every identifier replaced by the one returned by replace,
+// dropping duplicates. Identifiers replace returns false for are kept as they are.
+func replaceIdentifiers(identifiers []string, replace func(identifier string) (string, bool)) []string {
+	replaced := make([]string, 0, len(identifiers))
+	for _, id := range identifiers {
+		if key, ok := replace(id); ok {
+			id = key
+		}
+		if !slices.Contains(replaced, id) {
+			replaced = append(replaced, id)
+		}
+	}
+	return replaced
 }
 
-// Mute adds a ban to a users current ban, it moves their previous current ban to their pastBans.
-func (x *Xbox) Mute(b Punishment) {
+// AddDevice records a device-id the user has been seen on, it will return true if it managed to add it and false if
+// it was already recorded.
+func (x *Xbox) AddDevice(device string) bool {
 	x.lock.Lock()
 	defer x.lock.Unlock()
-	x.pastBans = append(x.pastBans, x.currentBan)
-	x.currentBan = b
+	if !slices.Contains(x.devices, device) {
+		x.devices = append(x.devices, device)
+		return true
+	}
+	return false
 }
 
-// MuteHistory returns the MuteHistory for the user, instead of returning a punishment it returns a []Data as it's meant to
-// be used for reading mutes only.
-func (x *Xbox) MuteHistory() []Punishment {
+// Devices returns all the device-ids the user has been seen on.
+func (x *Xbox) Devices() []string {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
-	return x.pastMutes
+	return x.devices
 }
 
 // Data returns the data representation for this punishment.
@@ -102,9 +144,9 @@ func (x *Xbox) Data() DataHolder {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
 	return &XboxData{
-		CurrentBan:  x.currentBan,
-		PastBans:    x.pastBans,
-		CurrentMute: x.currentMute,
-		PastMutes:   x.pastMutes,
+		Punishments: x.Punishments.data(),
+		Ips:         x.ips,
+		Devices:     x.devices,
+		Notes:       slices.Clone(x.notes),
 	}
 }